@@ -0,0 +1,263 @@
+package game
+
+type AbilityEffect int
+
+const (
+	DamageEffect AbilityEffect = iota
+	HealEffect
+	BlinkEffect
+	FearEffect
+)
+
+type AbilityTarget int
+
+const (
+	TargetSelf AbilityTarget = iota
+	TargetCreature
+	TargetTile
+)
+
+// AbilityDef describes a spell. Characters only keep the name and how long
+// until they can cast it again, so a new spell only needs a new entry.
+type AbilityDef struct {
+	Name        string
+	Effect      AbilityEffect
+	Target      AbilityTarget
+	Mana        int
+	Cooldown    int
+	Range       int
+	Radius      int
+	Power       int
+	Description string
+}
+
+// Ability is a spell a character knows. Cooldown counts the rounds left
+// before it can be cast again.
+type Ability struct {
+	Name     string
+	Cooldown int
+}
+
+var abilityDefs = map[string]*AbilityDef{}
+
+// abilityNames lists the spells in the order they were registered, for
+// picking one at random.
+var abilityNames []string
+
+func registerAbility(def *AbilityDef) {
+	abilityDefs[def.Name] = def
+	abilityNames = append(abilityNames, def.Name)
+}
+
+func init() {
+	registerAbility(&AbilityDef{Name: "Firebolt", Effect: DamageEffect, Target: TargetCreature, Mana: 8, Cooldown: 2, Range: 8, Power: 12,
+		Description: "Hurls a bolt of fire at a creature"})
+	registerAbility(&AbilityDef{Name: "Fireball", Effect: DamageEffect, Target: TargetCreature, Mana: 20, Cooldown: 6, Range: 7, Radius: 1, Power: 10,
+		Description: "Burns everything next to where it lands"})
+	registerAbility(&AbilityDef{Name: "Heal", Effect: HealEffect, Target: TargetSelf, Mana: 12, Cooldown: 5, Power: 40,
+		Description: "Restores 40 HP"})
+	registerAbility(&AbilityDef{Name: "Blink", Effect: BlinkEffect, Target: TargetTile, Mana: 10, Cooldown: 4, Range: 6,
+		Description: "Teleports to a tile in sight"})
+	registerAbility(&AbilityDef{Name: "Fear", Effect: FearEffect, Target: TargetCreature, Mana: 10, Cooldown: 6, Range: 8, Power: 8,
+		Description: "Makes a monster flee for 8 turns"})
+}
+
+func AbilityDefinition(name string) *AbilityDef {
+	def, exist := abilityDefs[name]
+	if !exist {
+		panic("unknown ability " + name)
+	}
+	return def
+}
+
+func (c *Character) knows(name string) bool {
+	for _, ability := range c.Abilities {
+		if ability.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Character) canCast(index int) bool {
+	ability := c.Abilities[index]
+	return ability.Cooldown == 0 && c.Mana >= AbilityDefinition(ability.Name).Mana
+}
+
+func (level *Level) readScroll(scroll *Items, character *Character) bool {
+	if character.knows(scroll.Spell) {
+		level.addEvent(Event{Kind: AbilityKnown, Actor: character.Name, Target: scroll.Spell, Pos: character.Pos})
+		return false
+	}
+	character.Abilities = append(character.Abilities, Ability{scroll.Spell, 0})
+	level.addEvent(Event{Kind: AbilityLearned, Actor: character.Name, Target: scroll.Spell, Pos: character.Pos})
+	return true
+}
+
+func inRange(from, to Pos, r int) bool {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	return dx*dx+dy*dy <= r*r
+}
+
+// canSeeTile tells whether caster has target in sight: in a player's field
+// of view, or on a clear line for a monster.
+func (level *Level) canSeeTile(caster *Character, target Pos) bool {
+	if player := level.playerOf(caster); player != nil {
+		return level.view(player).visible[target.Y][target.X]
+	}
+	clear := true
+	bresenham(caster.Pos, target, func(pos Pos) bool {
+		if pos != target && pos != caster.Pos && !canSee(level, pos.X, pos.Y) {
+			clear = false
+		}
+		return clear
+	})
+	return clear
+}
+
+// cast has caster use the ability at index on target and reports whether
+// it went off. Spells that miss their mark cost nothing.
+func (level *Level) cast(caster *Character, index int, target Pos) bool {
+	ability := &caster.Abilities[index]
+	def := AbilityDefinition(ability.Name)
+	fail := func(reason string) bool {
+		level.addEvent(Event{Kind: AbilityFailed, Actor: caster.Name, Target: def.Name + ", " + reason, Pos: caster.Pos})
+		return false
+	}
+	if ability.Cooldown > 0 {
+		return fail("it is not ready yet")
+	}
+	if caster.Mana < def.Mana {
+		return fail("they lack the mana")
+	}
+	if def.Target != TargetSelf {
+		if !level.inBounds(target.X, target.Y) || target == caster.Pos || !inRange(caster.Pos, target, def.Range) || !level.canSeeTile(caster, target) {
+			return fail("it can't reach there")
+		}
+	}
+	var monster *Monster
+	switch def.Effect {
+	case BlinkEffect:
+		if !canWalk(level, target.X, target.Y) || level.characterAt(target) != nil || level.Portals[target] != nil {
+			return fail("there is no room there")
+		}
+	case FearEffect:
+		monster = level.Monsters[target]
+		if monster == nil {
+			return fail("there is nothing to frighten")
+		}
+	}
+
+	caster.Mana -= def.Mana
+	ability.Cooldown = def.Cooldown
+	caster.Ap--
+	level.addEvent(Event{Kind: AbilityCast, Actor: caster.Name, Target: def.Name, Pos: target})
+	switch def.Effect {
+	case DamageEffect:
+		level.castDamage(caster, def, target)
+	case HealEffect:
+		healed := def.Power
+		if caster.Hp+healed > caster.MaxHp {
+			healed = caster.MaxHp - caster.Hp
+		}
+		caster.Hp += healed
+	case BlinkEffect:
+		level.teleportTo(caster, target)
+	case FearEffect:
+		monster.afraid = def.Power
+		level.addEvent(Event{Kind: Frightened, Actor: caster.Name, Target: monster.Name, Pos: monster.Pos})
+	}
+	return true
+}
+
+// castDamage flies the spell like a projectile and hurts what it hits, or
+// everything within its radius of where it lands.
+func (level *Level) castDamage(caster *Character, def *AbilityDef, target Pos) {
+	path := level.projectilePath(caster.Pos, target, def.Range)
+	if len(path) == 0 {
+		return
+	}
+	land := path[len(path)-1]
+	hits := make([]*Character, 0, 1)
+	for y := land.Y - def.Radius; y <= land.Y+def.Radius; y++ {
+		for x := land.X - def.Radius; x <= land.X+def.Radius; x++ {
+			if hit := level.characterAt(Pos{x, y}); hit != nil && hit != caster {
+				hits = append(hits, hit)
+			}
+		}
+	}
+	for _, hit := range hits {
+		level.damage(caster, hit, def.Power)
+	}
+	player := level.playerOf(caster)
+	for _, hit := range hits {
+		if monster := level.resolveHit(hit); monster != nil && player != nil {
+			player.gainExperience(monster.Experience, level)
+		}
+	}
+}
+
+func (level *Level) playerCast(player *Player, index int, target Pos) {
+	if index < 0 || index >= len(player.Abilities) {
+		return
+	}
+	if AbilityDefinition(player.Abilities[index].Name).Target == TargetSelf {
+		target = player.Pos
+	}
+	level.cast(&player.Character, index, target)
+}
+
+// castAbility has the monster heal itself when badly hurt, or else cast a
+// spell at a player it has a clear shot at but is not next to. It reports
+// whether the monster spent its turn.
+func (m *Monster) castAbility(level *Level) bool {
+	for i, ability := range m.Abilities {
+		if !m.canCast(i) {
+			continue
+		}
+		def := AbilityDefinition(ability.Name)
+		switch {
+		case def.Effect == HealEffect:
+			if m.Hp < m.MaxHp/2 {
+				return level.cast(&m.Character, i, m.Pos)
+			}
+		case def.Effect == DamageEffect:
+			for _, player := range level.alivePlayers() {
+				dx, dy := player.X-m.X, player.Y-m.Y
+				if dx >= -1 && dx <= 1 && dy >= -1 && dy <= 1 {
+					continue
+				}
+				path := level.projectilePath(m.Pos, player.Pos, def.Range)
+				if len(path) > 0 && path[len(path)-1] == player.Pos && inRange(m.Pos, player.Pos, def.Range) {
+					return level.cast(&m.Character, i, player.Pos)
+				}
+			}
+		}
+	}
+	return false
+}
+
+// tickAbilities counts down cooldowns and fear and brings back a little
+// mana at the end of a round.
+func (level *Level) tickAbilities() {
+	for _, player := range level.alivePlayers() {
+		player.tickAbilities()
+	}
+	for _, monster := range level.Monsters {
+		monster.tickAbilities()
+		if monster.afraid > 0 {
+			monster.afraid--
+		}
+	}
+}
+
+func (c *Character) tickAbilities() {
+	for i := range c.Abilities {
+		if c.Abilities[i].Cooldown > 0 {
+			c.Abilities[i].Cooldown--
+		}
+	}
+	if c.Mana < c.MaxMana {
+		c.Mana++
+	}
+}
@@ -0,0 +1,111 @@
+package game
+
+// autoAction is something a player keeps doing over several turns without
+// input until it is done or something needs their attention.
+type autoAction int
+
+const (
+	noAuto autoAction = iota
+	autoExplore
+	autoTravel
+	autoRest
+)
+
+// startAuto sets the player off on the action of input, if it is one that
+// takes several turns, and reports whether it did. Any other input stops
+// what the player was doing on their own.
+func (game *Game) startAuto(player *Player, input *Input) bool {
+	level := game.CurrentLevel
+	player.auto = noAuto
+	player.wounded = false
+	switch input.Input {
+	case Explore:
+		player.auto = autoExplore
+	case Travel:
+		player.auto = autoTravel
+		player.travelTo = input.Target
+	case TravelToStairs:
+		player.auto = autoTravel
+		player.travelTo = Pos{-1, -1}
+		if pos, found := level.nearestSeen(player, level.isStairs); found {
+			player.travelTo = pos
+		}
+	case TravelToCoin:
+		player.auto = autoTravel
+		player.travelTo = Pos{-1, -1}
+		if pos, found := level.nearestSeen(player, level.isCoin); found {
+			player.travelTo = pos
+		}
+	case Rest:
+		player.auto = autoRest
+		player.rested = 0
+	default:
+		return false
+	}
+
+	player.inView = make(map[*Monster]bool)
+	view := level.view(player)
+	for pos, monster := range level.Monsters {
+		if view.visible[pos.Y][pos.X] {
+			player.inView[monster] = true
+		}
+	}
+	return true
+}
+
+// autoStep plays one turn of what the player is doing on their own.
+func (game *Game) autoStep(player *Player) {
+	level := game.CurrentLevel
+	level.TurnEvents = nil
+	// Monsters may have struck since the last step, while other players
+	// took their turns.
+	if player.wounded {
+		level.interruptAuto(player, "they were hurt")
+		return
+	}
+	var action *Input
+	ok := false
+	switch player.auto {
+	case autoExplore:
+		action, ok = level.exploreAction(player)
+	case autoTravel:
+		action, ok = level.travelAction(player)
+	case autoRest:
+		action, ok = level.restAction(player)
+	}
+	if !ok {
+		player.auto = noAuto
+		return
+	}
+
+	from, items := player.Pos, len(level.Items[player.Pos])
+	game.playTurn(player, action)
+	switch {
+	case game.CurrentLevel != level || player.Dead:
+		player.auto = noAuto
+	case player.wounded:
+		level.interruptAuto(player, "they were hurt")
+	case action.Input == TakeAllItems && len(level.Items[player.Pos]) == items:
+		player.auto = noAuto
+	// Stepping again would fail again, so the player stops rather than
+	// using up turns standing still.
+	case level.HasEvent(FailedPortal):
+		level.interruptAuto(player, "the party can't pay for the stairs")
+	case player.auto == autoTravel && player.Pos == from:
+		level.interruptAuto(player, "the way is blocked")
+	case player.auto == autoTravel && player.Pos == player.travelTo:
+		player.auto = noAuto
+	}
+}
+
+func (level *Level) interruptAuto(player *Player, reason string) {
+	kind := ExploreInterrupted
+	switch player.auto {
+	case autoTravel:
+		kind = TravelInterrupted
+	case autoRest:
+		kind = RestInterrupted
+	}
+	player.auto = noAuto
+	level.addEvent(Event{Kind: kind, Actor: player.Name, Target: reason, Pos: player.Pos})
+}
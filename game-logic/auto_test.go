@@ -0,0 +1,55 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestHurtInterruptsRest(t *testing.T) {
+	game := newGame(1, rand.New(rand.NewSource(1)))
+	level := game.CurrentLevel
+	player := game.Players[0]
+	player.Hp = player.MaxHp - 20
+	if !game.startAuto(player, &Input{Input: Rest}) {
+		t.Fatal("player did not start resting")
+	}
+	game.autoStep(player)
+	if player.auto != autoRest {
+		t.Fatal("rest stopped on its own")
+	}
+
+	// A hit that regeneration makes up for still wakes the player.
+	monster := level.monsterOrder()[0]
+	level.damage(&monster.Character, &player.Character, 1)
+	player.Hp++
+	game.autoStep(player)
+	if player.auto != noAuto {
+		t.Fatal("player kept resting after being hurt")
+	}
+	if events := level.TurnEvents; len(events) == 0 || events[len(events)-1].Kind != RestInterrupted {
+		t.Errorf("got events %v, want the rest to be interrupted", events)
+	}
+}
+
+func TestTravelToStairsStopsWithoutCoins(t *testing.T) {
+	game := newTestGame([]string{
+		"&&&&&",
+		"&@,,&",
+		"&&&&&",
+	})
+	level := game.CurrentLevel
+	level.Portals[Pos{3, 1}] = &LevelPos{level, level.start}
+	player := game.Players[0]
+	if !game.startAuto(player, &Input{Input: TravelToStairs}) {
+		t.Fatal("player did not start travelling")
+	}
+	for i := 0; i < 10 && player.auto != noAuto; i++ {
+		game.autoStep(player)
+	}
+	if player.auto != noAuto {
+		t.Fatal("player kept stepping onto stairs the party can't pay for")
+	}
+	if events := level.TurnEvents; len(events) == 0 || events[len(events)-1].Kind != TravelInterrupted {
+		t.Errorf("got events %v, want the travel to be interrupted", events)
+	}
+}
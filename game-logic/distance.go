@@ -0,0 +1,145 @@
+package game
+
+import "math"
+
+const (
+	unreachable     = math.MaxInt32
+	fleeCoefficient = -1.2
+)
+
+type distanceKey struct {
+	flee         bool
+	throughDoors bool
+}
+
+func (level *Level) passable(pos Pos, throughDoors bool) bool {
+	if !level.inBounds(pos.X, pos.Y) {
+		return false
+	}
+	return level.Map[pos.Y][pos.X].walkable() || (throughDoors && isClosedDoor(level, pos.X, pos.Y))
+}
+
+// adjacent returns the neighbours of pos that a creature could stand on,
+// ignoring other creatures so that distance maps stay valid while
+// monsters move around. Closed doors count when throughDoors is set. The
+// neighbours are appended to res, which may be reused between calls.
+func (level *Level) adjacent(pos Pos, throughDoors bool, res []Pos) []Pos {
+	res = res[:0]
+	for _, next := range [4]Pos{{pos.X, pos.Y - 1}, {pos.X - 1, pos.Y}, {pos.X + 1, pos.Y}, {pos.X, pos.Y + 1}} {
+		if level.passable(next, throughDoors) {
+			res = append(res, next)
+		}
+	}
+	if level.Diagonal {
+		for _, next := range [4]Pos{{pos.X - 1, pos.Y - 1}, {pos.X + 1, pos.Y - 1}, {pos.X - 1, pos.Y + 1}, {pos.X + 1, pos.Y + 1}} {
+			if level.passable(next, throughDoors) && canCutCorner(level, pos, next) {
+				res = append(res, next)
+			}
+		}
+	}
+	return res
+}
+
+func (level *Level) newDistanceMap() [][]int {
+	dist := make([][]int, len(level.Map))
+	for y := range dist {
+		dist[y] = make([]int, len(level.Map[y]))
+		for x := range dist[y] {
+			dist[y][x] = unreachable
+		}
+	}
+	return dist
+}
+
+// relax runs Dijkstra from every cell that already holds a value, using
+// the movement cost of each tile, until no cell can be lowered.
+func (level *Level) relax(dist [][]int, throughDoors bool) {
+	pq := make(priorityQueue, 0, 64)
+	for y := range dist {
+		for x, d := range dist[y] {
+			if d != unreachable {
+				pq = pq.push(Pos{x, y}, d)
+			}
+		}
+	}
+
+	var curr Pos
+	adjs := make([]Pos, 0, 8)
+	for len(pq) > 0 {
+		pq, curr = pq.pop()
+		adjs = level.adjacent(curr, throughDoors, adjs)
+		for _, next := range adjs {
+			if level.isKnownTrap(next) {
+				continue
+			}
+			newCost := dist[curr.Y][curr.X] + movementCost(level, next)
+			if newCost < dist[next.Y][next.X] {
+				dist[next.Y][next.X] = newCost
+				pq = pq.push(next, newCost)
+			}
+		}
+	}
+}
+
+func (level *Level) computeChaseMap(throughDoors bool) [][]int {
+	dist := level.newDistanceMap()
+	for _, player := range level.alivePlayers() {
+		dist[player.Y][player.X] = 0
+	}
+	level.relax(dist, throughDoors)
+	return dist
+}
+
+// computeFleeMap inverts the chase map so that rolling downhill leads away
+// from the player, preferring open areas over dead ends nearby.
+func (level *Level) computeFleeMap(chase [][]int, throughDoors bool) [][]int {
+	dist := level.newDistanceMap()
+	for y := range chase {
+		for x, d := range chase[y] {
+			if d != unreachable {
+				dist[y][x] = int(float64(d) * fleeCoefficient)
+			}
+		}
+	}
+	level.relax(dist, throughDoors)
+	return dist
+}
+
+func (level *Level) invalidateDistanceMaps() {
+	level.distanceMaps = nil
+}
+
+func (level *Level) getDistanceMap(flee, throughDoors bool) [][]int {
+	if level.distanceMaps == nil {
+		level.distanceMaps = make(map[distanceKey][][]int)
+	}
+	key := distanceKey{flee, throughDoors}
+	dist, exist := level.distanceMaps[key]
+	if !exist {
+		if flee {
+			dist = level.computeFleeMap(level.getDistanceMap(false, throughDoors), throughDoors)
+		} else {
+			dist = level.computeChaseMap(throughDoors)
+		}
+		level.distanceMaps[key] = dist
+	}
+	return dist
+}
+
+// downhill picks the free neighbour with the lowest value that is lower
+// than the current one. Neighbours taken by other monsters are skipped so
+// a group spreads around the player instead of queueing behind each other.
+func (level *Level) downhill(dist [][]int, from Pos, throughDoors bool) (Pos, bool) {
+	best := from
+	bestValue := dist[from.Y][from.X]
+	for _, next := range level.adjacent(from, throughDoors, make([]Pos, 0, 8)) {
+		if _, exist := level.Monsters[next]; exist {
+			continue
+		}
+		if dist[next.Y][next.X] < bestValue {
+			best = next
+			bestValue = dist[next.Y][next.X]
+		}
+	}
+	return best, best != from
+}
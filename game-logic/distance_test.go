@@ -0,0 +1,38 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func newCrowdedLevel(w, h int) *Level {
+	rng := rand.New(rand.NewSource(1))
+	level := newLevel(generateMap(w, h, rng), &MessageLog{}, rng)
+	level.Players = []*Player{newPlayer(0)}
+	level.placeParty(level.Players, Pos{w / 2, h / 2})
+	return level
+}
+
+// BenchmarkMonsterUpdate compares finding every monster's next step for a
+// round through the shared distance maps with one A* search per monster.
+func BenchmarkMonsterUpdate(b *testing.B) {
+	level := newCrowdedLevel(96, 96)
+	goal := level.Players[0].Pos
+	b.Logf("%d monsters", len(level.Monsters))
+
+	b.Run("DistanceMaps", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			level.invalidateDistanceMaps()
+			for _, m := range level.Monsters {
+				level.downhill(level.getDistanceMap(m.Hp < m.MaxHp/4, m.OpensDoors), m.Pos, m.OpensDoors)
+			}
+		}
+	})
+	b.Run("AStar", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, m := range level.Monsters {
+				level.aStar(m.Pos, goal)
+			}
+		}
+	})
+}
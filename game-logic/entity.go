@@ -1,5 +1,7 @@
 package game
 
+import "sort"
+
 type Entity struct {
 	Pos
 	Rune     rune
@@ -9,16 +11,129 @@ type Entity struct {
 type Character struct {
 	Entity
 	Hp       int
+	MaxHp    int
 	Strength int
 	Speed    float64
 	Ap float64
 	SightRange int
 	Items []*Items
-	Sword, Armour, Helmet *Items
+	Sword, Armour, Helmet, Ranged *Items
+	Poison int
+	regen int
+	Mana, MaxMana int
+	Abilities []Ability
 }
 
 type Player struct{
 	Character
+	ID         int
+	Level      int
+	Experience int
+	Coins      int
+	Dead       bool
+	QuickSlots [QuickSlotCount]string
+	acted      bool
+	away       bool
+	auto       autoAction
+	travelTo   Pos
+	rested     int
+	inView     map[*Monster]bool
+	wounded    bool
+}
+
+const QuickSlotCount = 9
+
+func (p *Player) QuickSlotItem(slot int) *Items {
+	if slot < 0 || slot >= QuickSlotCount || p.QuickSlots[slot] == "" {
+		return nil
+	}
+	for _, item := range p.Items {
+		if item.Name == p.QuickSlots[slot] {
+			return item
+		}
+	}
+	return nil
+}
+
+const maxSightRange = 15
+
+func ExperienceToLevelUp(level int) int {
+	return level * 50
+}
+
+func (p *Player) gainExperience(exp int, level *Level) {
+	p.Experience += exp
+	for p.Experience >= ExperienceToLevelUp(p.Level) {
+		p.Experience -= ExperienceToLevelUp(p.Level)
+		p.Level++
+		p.Strength += 2
+		p.MaxHp += 20
+		p.Hp += 20
+		p.MaxMana += 5
+		p.Mana += 5
+		if p.SightRange < maxSightRange {
+			p.SightRange++
+		}
+		level.addEvent(Event{Kind: LevelUp, Actor: p.Name, Amount: p.Level, Pos: p.Pos})
+	}
+}
+
+// killPlayer leaves the player's body out of the game. The game is over
+// once the whole party is dead.
+func (level *Level) killPlayer(player *Player) {
+	if player.Dead {
+		return
+	}
+	player.Dead = true
+	level.invalidateDistanceMaps()
+	level.onDeath(&player.Character)
+	if len(level.alivePlayers()) == 0 {
+		level.game.over = true
+	}
+}
+
+func (level *Level) alivePlayers() []*Player {
+	alive := make([]*Player, 0, len(level.Players))
+	for _, player := range level.Players {
+		if !player.Dead {
+			alive = append(alive, player)
+		}
+	}
+	return alive
+}
+
+// monsterOrder lists the monsters from the top left of the level, so they
+// act in the same order on every run.
+func (level *Level) monsterOrder() []*Monster {
+	monsters := make([]*Monster, 0, len(level.Monsters))
+	for _, monster := range level.Monsters {
+		monsters = append(monsters, monster)
+	}
+	sort.Slice(monsters, func(i, j int) bool {
+		if monsters[i].Y != monsters[j].Y {
+			return monsters[i].Y < monsters[j].Y
+		}
+		return monsters[i].X < monsters[j].X
+	})
+	return monsters
+}
+
+func (level *Level) playerAt(pos Pos) *Player {
+	for _, player := range level.Players {
+		if !player.Dead && player.Pos == pos {
+			return player
+		}
+	}
+	return nil
+}
+
+func (level *Level) playerOf(character *Character) *Player {
+	for _, player := range level.Players {
+		if &player.Character == character {
+			return player
+		}
+	}
+	return nil
 }
 
 func (level *Level) Attack(c1, c2 *Character) {
@@ -28,17 +143,31 @@ func (level *Level) Attack(c1, c2 *Character) {
 	if c1.Sword != nil {
 		c1AP *= int(c1.Sword.Power)
 	}
+	level.damage(c1, c2, c1AP)
+}
+
+// hurt takes amount from the character's HP and, for a player, notes it
+// so that whatever they are doing on their own stops.
+func (level *Level) hurt(c *Character, amount int) {
+	c.Hp -= amount
+	if player := level.playerOf(c); player != nil && amount > 0 {
+		player.wounded = true
+	}
+}
+
+// damage hurts c2 by c1AP, less what its helmet and armour block.
+func (level *Level) damage(c1, c2 *Character, c1AP int) {
 	if c2.Helmet != nil {
 		c1AP = int(float32(c1AP)*(1.0-c2.Helmet.Power))
 	}
 	if c2.Armour != nil {
 		c1AP = int(float32(c1AP)*(1.0-c2.Armour.Power))
 	}
-	c2.Hp -= c1AP
+	level.hurt(c2, c1AP)
+	level.onAttack(c1, c2, c1AP)
 
-	if c2.Hp > 0 {
-		level.addEvent(c1.Name + " attacked " + c2.Name)
-	} else {
-		level.addEvent(c1.Name + " killed " + c2.Name)
+	level.addEvent(Event{Kind: Attacking, Actor: c1.Name, Target: c2.Name, Amount: c1AP, Pos: c2.Pos})
+	if c2.Hp <= 0 {
+		level.addEvent(Event{Kind: MonsterDeath, Actor: c1.Name, Target: c2.Name, Pos: c2.Pos})
 	}
 }
\ No newline at end of file
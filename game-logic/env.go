@@ -0,0 +1,170 @@
+package game
+
+import "math/rand"
+
+// Rewards says how much each outcome of a step is worth to an agent.
+type Rewards struct {
+	Coin          float64
+	Kill          float64
+	LevelComplete float64
+	Death         float64
+	Step          float64
+}
+
+var DefaultRewards = Rewards{Coin: 1, Kill: 1, LevelComplete: 10, Death: -10, Step: -0.01}
+
+// Observation is what the player knows after a step. Tiles holds the
+// floor, or the door or other overlay on it, of every tile the player has
+// seen, and Blank elsewhere. Entities holds the runes of the players,
+// monsters, items and known traps the player can see.
+type Observation struct {
+	Tiles      [][]rune
+	Entities   [][]rune
+	Visible    [][]bool
+	Seen       [][]bool
+	Pos        Pos
+	Hp         int
+	MaxHp      int
+	Mana       int
+	Level      int
+	Experience int
+	Coins      int
+	Poison     int
+	Items      int
+}
+
+// StepInfo explains a step: what happened during it and on which turn.
+type StepInfo struct {
+	Turn   int
+	Events []Event
+}
+
+// Env runs a single player game without windows or channels, one action
+// at a time, for agents to learn on.
+type Env struct {
+	Rewards  Rewards
+	MaxSteps int
+	game     *Game
+	steps    int
+}
+
+func NewEnv() *Env {
+	return &Env{Rewards: DefaultRewards}
+}
+
+// Reset starts a new episode. Episodes with the same seed play out the
+// same way for the same actions.
+func (env *Env) Reset(seed int64) Observation {
+	env.game = newGame(0, rand.New(rand.NewSource(seed)))
+	env.steps = 0
+	return env.observe()
+}
+
+// Step plays action as the player's turn. The Player field of action is
+// ignored. Explore and the travel actions keep playing turns until they
+// stop, and each of them counts as a step and towards the reward. The
+// episode is done once the player dies or MaxSteps, when it is not zero,
+// is reached.
+func (env *Env) Step(action Input) (Observation, float64, bool, StepInfo) {
+	if env.game == nil {
+		panic("the environment has to be reset before stepping")
+	}
+	player := env.game.Players[0]
+	if action.Input == Quit || action.Input == CloseWindow || action.Input == Join || action.Input == Leave {
+		action.Input = None
+	}
+
+	var events []Event
+	reward := 0.0
+	auto := env.game.startAuto(player, &action)
+	for !env.done() {
+		if auto {
+			env.game.autoStep(player)
+		} else {
+			env.game.playTurn(player, &action)
+		}
+		env.steps++
+		reward += env.Rewards.Step
+		events = append(events, env.game.CurrentLevel.TurnEvents...)
+		if !auto || player.auto == noAuto {
+			break
+		}
+	}
+
+	for _, e := range events {
+		switch {
+		case e.Kind == CollectCoin && e.Actor == player.Name:
+			reward += env.Rewards.Coin
+		case e.Kind == MonsterDeath && e.Actor == player.Name:
+			reward += env.Rewards.Kill
+		case e.Kind == Portal:
+			reward += env.Rewards.LevelComplete
+		}
+	}
+	if env.game.over {
+		reward += env.Rewards.Death
+	}
+	return env.observe(), reward, env.done(), StepInfo{env.game.CurrentLevel.Log.Turn, events}
+}
+
+func (env *Env) done() bool {
+	return env.game.over || (env.MaxSteps > 0 && env.steps >= env.MaxSteps)
+}
+
+func (env *Env) observe() Observation {
+	level := env.game.CurrentLevel
+	player := env.game.Players[0]
+	view := level.view(player)
+
+	obs := Observation{
+		Tiles:      make([][]rune, len(level.Map)),
+		Entities:   make([][]rune, len(level.Map)),
+		Visible:    make([][]bool, len(level.Map)),
+		Seen:       make([][]bool, len(level.Map)),
+		Pos:        player.Pos,
+		Hp:         player.Hp,
+		MaxHp:      player.MaxHp,
+		Mana:       player.Mana,
+		Level:      player.Level,
+		Experience: player.Experience,
+		Coins:      player.Coins,
+		Poison:     player.Poison,
+		Items:      len(player.Items),
+	}
+	for y, row := range level.Map {
+		obs.Tiles[y] = make([]rune, len(row))
+		obs.Entities[y] = make([]rune, len(row))
+		obs.Visible[y] = append([]bool(nil), view.visible[y]...)
+		obs.Seen[y] = append([]bool(nil), view.seen[y]...)
+		for x, tile := range row {
+			if !view.seen[y][x] {
+				continue
+			}
+			obs.Tiles[y][x] = tile.Rune
+			if tile.OverlayRune != Blank {
+				obs.Tiles[y][x] = tile.OverlayRune
+			}
+		}
+	}
+
+	set := func(pos Pos, r rune) {
+		if view.visible[pos.Y][pos.X] {
+			obs.Entities[pos.Y][pos.X] = r
+		}
+	}
+	for pos, trap := range level.Traps {
+		if !trap.Hidden {
+			set(pos, trap.Rune)
+		}
+	}
+	for pos, items := range level.Items {
+		if len(items) > 0 {
+			set(pos, items[len(items)-1].Rune)
+		}
+	}
+	for pos, monster := range level.Monsters {
+		set(pos, monster.Rune)
+	}
+	set(player.Pos, player.Rune)
+	return obs
+}
@@ -0,0 +1,67 @@
+package game
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+var envActions = []InputState{Left, Right, Up, Down, Search, TakeAllItems, Explore, TravelToCoin, TravelToStairs, Rest}
+
+// playEpisode resets env with seed and plays actions picked at random by
+// the same seed, recording every step and the monsters after it.
+func playEpisode(env *Env, seed int64, steps int) []interface{} {
+	rng := rand.New(rand.NewSource(seed))
+	record := []interface{}{env.Reset(seed)}
+	for i := 0; i < steps; i++ {
+		obs, reward, done, info := env.Step(Input{Input: envActions[rng.Intn(len(envActions))]})
+		record = append(record, obs, reward, done, info)
+		for _, monster := range env.game.CurrentLevel.monsterOrder() {
+			record = append(record, monsterState(monster))
+		}
+		if done {
+			break
+		}
+	}
+	return record
+}
+
+// monsterState is what a monster is doing, leaving out the ids of its
+// items, which are unique for the whole process.
+func monsterState(monster *Monster) []interface{} {
+	state := []interface{}{monster.Pos, monster.Hp, monster.Ap, monster.Mana, monster.Poison, monster.afraid, monster.Abilities}
+	for _, item := range monster.Items {
+		state = append(state, item.Name, item.Count)
+	}
+	return state
+}
+
+func TestEnvIsDeterministic(t *testing.T) {
+	env := NewEnv()
+	env.MaxSteps = 1000
+	for seed := int64(1); seed <= 20; seed++ {
+		first := playEpisode(env, seed, 1000)
+		second := playEpisode(env, seed, 1000)
+		if len(first) != len(second) {
+			t.Fatalf("seed %d: episodes took %d and %d records", seed, len(first), len(second))
+		}
+		for i := range first {
+			if !reflect.DeepEqual(first[i], second[i]) {
+				t.Fatalf("seed %d: episodes differ at record %d", seed, i)
+			}
+		}
+	}
+}
+
+func BenchmarkEnvStep(b *testing.B) {
+	env := NewEnv()
+	env.MaxSteps = 100
+	rng := rand.New(rand.NewSource(1))
+	env.Reset(1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, done, _ := env.Step(Input{Input: envActions[rng.Intn(4)]}); done {
+			env.Reset(int64(i))
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package game
+
+import "strconv"
+
+// Event is a single thing that happened during a turn. Front-ends get the
+// events of the last turn in Level.TurnEvents and the log text is built
+// from them with String.
+type Event struct {
+	Kind   GameEvent
+	Actor  string
+	Target string
+	Amount int
+	Pos    Pos
+	Turn   int
+}
+
+// MessageLog keeps every logged event of the run. All levels share the
+// same log, the same way they share the player.
+type MessageLog struct {
+	Turn    int
+	Entries []Event
+}
+
+func (e Event) String() string {
+	switch e.Kind {
+	case OpenDoor:
+		return e.Actor + " opened a door"
+	case Portal:
+		return e.Actor + " took the stairs"
+	case FailedPortal:
+		return "Collect 5 coins before taking the stairs"
+	case Attacking:
+		return e.Actor + " hit " + e.Target + " for " + strconv.Itoa(e.Amount)
+	case PickUpItems:
+		return e.Actor + " picked up " + e.Target
+	case DropItems:
+		return e.Actor + " dropped " + e.Target
+	case EquipItems:
+		return e.Actor + " equipped " + e.Target
+	case MonsterDeath:
+		if e.Actor == "" {
+			return e.Target + " died"
+		}
+		return e.Actor + " killed " + e.Target
+	case DrinkPotion:
+		return e.Actor + " drank " + e.Target + " and healed " + strconv.Itoa(e.Amount)
+	case LevelUp:
+		return e.Actor + " reached level " + strconv.Itoa(e.Amount)
+	case InventoryFull:
+		return "Your inventory is full"
+	case DoorLocked:
+		return "The door is locked"
+	case UnlockDoor:
+		return e.Actor + " unlocked the door"
+	case TrapTriggered:
+		return e.Actor + " triggered a " + e.Target
+	case TrapFound:
+		return e.Actor + " found a " + e.Target
+	case Searched:
+		return e.Actor + " found nothing"
+	case AlarmRaised:
+		return "An alarm rings out"
+	case CollectCoin:
+		return e.Actor + " picked up a coin"
+	case Explored:
+		return "There is nothing left to explore"
+	case ExploreInterrupted:
+		return e.Actor + " stopped exploring, " + e.Target
+	case TravelInterrupted:
+		return e.Actor + " stopped travelling, " + e.Target
+	case Rested:
+		return e.Actor + " feels rested"
+	case RestInterrupted:
+		return e.Actor + " stopped resting, " + e.Target
+	case Fired:
+		return e.Actor + " fired " + e.Target
+	case NothingToFire:
+		return e.Actor + " has nothing to fire"
+	case Webbed:
+		return e.Target + " is caught in a web"
+	case AbilityLearned:
+		return e.Actor + " learned " + e.Target
+	case AbilityKnown:
+		return e.Actor + " already knows " + e.Target
+	case AbilityCast:
+		return e.Actor + " cast " + e.Target
+	case AbilityFailed:
+		return e.Actor + " could not cast " + e.Target
+	case Frightened:
+		return e.Target + " flees in fear"
+	}
+	return ""
+}
+
+func (level *Level) addEvent(e Event) {
+	e.Turn = level.Log.Turn
+	level.TurnEvents = append(level.TurnEvents, e)
+	if e.String() != "" {
+		level.Log.Entries = append(level.Log.Entries, e)
+	}
+}
+
+func (level *Level) HasEvent(kind GameEvent) bool {
+	for _, e := range level.TurnEvents {
+		if e.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
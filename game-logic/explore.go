@@ -0,0 +1,126 @@
+package game
+
+// exploreTarget finds the nearest place worth walking to: a tile the
+// player has not seen, or a seen tile with a coin or items while there is
+// room for them. A closed door on the way, or a locked one the player has
+// the key to, counts as a target too, reached from the tile next to it,
+// which is returned as from.
+func (level *Level) exploreTarget(player *Player) (target, from Pos, found bool) {
+	view := level.view(player)
+	room := len(player.Items) < InventorySlots
+	keys := make(map[string]bool)
+	for _, item := range player.Items {
+		if item.Type == Key {
+			keys[item.KeyID] = true
+		}
+	}
+	queue := []Pos{player.Pos}
+	cameFrom := map[Pos]Pos{player.Pos: player.Pos}
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+		if curr != player.Pos {
+			if !view.seen[curr.Y][curr.X] {
+				return curr, cameFrom[curr], true
+			}
+			if level.Map[curr.Y][curr.X].OverlayRune == Coin || (room && len(level.Items[curr]) > 0) {
+				return curr, cameFrom[curr], true
+			}
+		}
+		for _, next := range level.adjacent(curr, true, make([]Pos, 0, 8)) {
+			if _, visited := cameFrom[next]; visited || level.isKnownTrap(next) || level.Portals[next] != nil {
+				continue
+			}
+			cameFrom[next] = curr
+			if isClosedDoor(level, next.X, next.Y) && view.seen[next.Y][next.X] {
+				return next, curr, true
+			}
+			queue = append(queue, next)
+		}
+		for _, next := range [4]Pos{{curr.X, curr.Y - 1}, {curr.X - 1, curr.Y}, {curr.X + 1, curr.Y}, {curr.X, curr.Y + 1}} {
+			if isLockedDoor(level, next.X, next.Y) && view.seen[next.Y][next.X] && level.canPassWithKeys(next, keys) {
+				return next, curr, true
+			}
+		}
+	}
+	return Pos{}, Pos{}, false
+}
+
+func (level *Level) monsterInView(player *Player) *Monster {
+	view := level.view(player)
+	for _, monster := range level.monsterOrder() {
+		if view.visible[monster.Y][monster.X] {
+			return monster
+		}
+	}
+	return nil
+}
+
+// monsterCameIntoView returns a monster the player sees that was not in
+// view when they set off on their own, if there is one.
+func (level *Level) monsterCameIntoView(player *Player) *Monster {
+	view := level.view(player)
+	for _, monster := range level.monsterOrder() {
+		if view.visible[monster.Y][monster.X] && !player.inView[monster] {
+			return monster
+		}
+	}
+	return nil
+}
+
+func directionTo(from, to Pos) InputState {
+	switch {
+	case to.X < from.X && to.Y < from.Y:
+		return UpLeft
+	case to.X > from.X && to.Y < from.Y:
+		return UpRight
+	case to.X < from.X && to.Y > from.Y:
+		return DownLeft
+	case to.X > from.X && to.Y > from.Y:
+		return DownRight
+	case to.X < from.X:
+		return Left
+	case to.X > from.X:
+		return Right
+	case to.Y < from.Y:
+		return Up
+	}
+	return Down
+}
+
+// exploreAction decides the player's next exploring move. When there is
+// none it tells the player why and returns false.
+func (level *Level) exploreAction(player *Player) (*Input, bool) {
+	stop := func(kind GameEvent, reason string) (*Input, bool) {
+		level.addEvent(Event{Kind: kind, Actor: player.Name, Target: reason, Pos: player.Pos})
+		return nil, false
+	}
+
+	if monster := level.monsterCameIntoView(player); monster != nil {
+		return stop(ExploreInterrupted, monster.Name+" came into view")
+	}
+	if len(level.Items[player.Pos]) > 0 && len(player.Items) < InventorySlots {
+		return &Input{Input: TakeAllItems, Player: player.ID}, true
+	}
+
+	target, from, found := level.exploreTarget(player)
+	if !found {
+		return stop(Explored, "")
+	}
+	goal := target
+	if isClosedDoor(level, target.X, target.Y) || isLockedDoor(level, target.X, target.Y) {
+		goal = from
+	}
+	next := target
+	if goal != player.Pos {
+		path := level.aStar(player.Pos, goal)
+		if len(path) < 2 {
+			return stop(ExploreInterrupted, "the way is blocked")
+		}
+		next = path[1]
+	}
+	if level.playerAt(next) != nil || level.Portals[next] != nil {
+		return stop(ExploreInterrupted, "the way is blocked")
+	}
+	return &Input{Input: directionTo(player.Pos, next), Player: player.ID}, true
+}
@@ -0,0 +1,38 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func newTestGame(lines []string) *Game {
+	level := newLevel(lines, &MessageLog{}, rand.New(rand.NewSource(1)))
+	level.Players = []*Player{newPlayer(0)}
+	game := &Game{CurrentLevel: level, Players: level.Players, turnCost: 1, watchers: &spectatorList{}, rng: rand.New(rand.NewSource(1))}
+	level.game = game
+	level.placeParty(level.Players, level.start)
+	return game
+}
+
+func TestExploreStopsForMonstersComingIntoView(t *testing.T) {
+	game := newTestGame([]string{
+		"&&&&&&&&&&&&&&&&",
+		"&R,,@,,,,,|,,,R&",
+		"&&&&&&&&&&&&&&&&",
+	})
+	player := game.Players[0]
+	start := player.Pos
+	if !game.startAuto(player, &Input{Input: Explore}) {
+		t.Fatal("player did not start exploring")
+	}
+	for i := 0; i < 50 && player.auto != noAuto; i++ {
+		game.autoStep(player)
+	}
+	if player.Pos == start {
+		t.Fatal("the rat in view when exploring began stopped the player")
+	}
+	events := game.CurrentLevel.TurnEvents
+	if len(events) == 0 || events[len(events)-1].Kind != ExploreInterrupted || events[len(events)-1].Target != "Rat came into view" {
+		t.Errorf("got events %v, want exploring to stop for the rat behind the door", events)
+	}
+}
@@ -1,5 +1,10 @@
 package game
 
+import (
+	"math/rand"
+	"time"
+)
+
 type GameEvent int
 
 const (
@@ -13,6 +18,28 @@ const (
 	MonsterDeath
 	DrinkPotion
 	FailedPortal
+	LevelUp
+	InventoryFull
+	DoorLocked
+	UnlockDoor
+	TrapTriggered
+	Searched
+	TrapFound
+	AlarmRaised
+	CollectCoin
+	Explored
+	ExploreInterrupted
+	TravelInterrupted
+	Rested
+	RestInterrupted
+	Fired
+	NothingToFire
+	Webbed
+	AbilityLearned
+	AbilityKnown
+	AbilityCast
+	AbilityFailed
+	Frightened
 )
 
 type Game struct {
@@ -20,50 +47,200 @@ type Game struct {
 	InputChan chan *Input
 	Levels map[string]*Level
 	CurrentLevel *Level
+	Players []*Player
+	turnCost int
+	hooks []Hook
+	windows map[chan *Level]*Player
+	watchers *spectatorList
+	rng *rand.Rand
+	over bool
 }
 
+// NewGame starts a game with one player per window, and a single player
+// when there are no windows at all.
 func NewGame(numWindows int) *Game {
+	return newGame(numWindows, rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+func newGame(numWindows int, rng *rand.Rand) *Game {
 	levelChan := make([]chan *Level, numWindows)
 	for i := range levelChan {
-		levelChan[i] = make(chan *Level)
+		levelChan[i] = make(chan *Level, 1)
 	}
 	inputChan := make(chan *Input)
-	levels := loadLevels()
-	
-	game := &Game{levelChan, inputChan, levels, nil}
+	levels := loadLevels(rng)
+
+	players := make([]*Player, 0, numWindows)
+	for i := 0; i < numWindows || i == 0; i++ {
+		players = append(players, newPlayer(i))
+	}
+	windows := make(map[chan *Level]*Player, numWindows)
+	for i, lchan := range levelChan {
+		windows[lchan] = players[i]
+	}
+
+	game := &Game{levelChan, inputChan, levels, nil, players, 1, nil, windows, &spectatorList{}, rng, false}
+	for _, level := range levels {
+		level.game = game
+		level.Players = players
+	}
 	game.loadWorldFile()
-	game.CurrentLevel.lineOfSight()
+	game.loadPropertiesFile()
+	game.validateLocks()
+	game.CurrentLevel.placeParty(players, game.CurrentLevel.start)
 	return game
 }
 
-func (game *Game) Run() {
+// Player returns the player with the given id, or nil if there is none.
+func (game *Game) Player(id int) *Player {
+	if id < 0 || id >= len(game.Players) {
+		return nil
+	}
+	return game.Players[id]
+}
+
+// endRound lets the monsters move once every living player that is still
+// playing has acted, for as long as the slowest move of the round took.
+func (game *Game) endRound() bool {
+	acted := false
+	for _, player := range game.Players {
+		if !player.Dead && !player.away && !player.acted {
+			return false
+		}
+		acted = acted || player.acted
+	}
+	if !acted {
+		return false
+	}
+
+	level := game.CurrentLevel
+	for i := 0; i < game.turnCost; i++ {
+		for _, monster := range level.monsterOrder() {
+			// Skip monsters killed earlier in the round.
+			if level.Monsters[monster.Pos] == monster {
+				monster.Update(level)
+			}
+		}
+	}
+	level.tickPoison()
+	level.regenerate()
+	level.tickAbilities()
+	for _, player := range game.Players {
+		player.acted = false
+	}
+	game.turnCost = 1
+	return true
+}
+
+// sendLevels replaces whatever level a window has not picked up yet, so
+// the game never waits on a window, not even while it plays turns on its
+// own.
+func (game *Game) sendLevels() {
 	for _, lchan := range game.LevelChan {
-		lchan <- game.CurrentLevel
+		select {
+		case <-lchan:
+		default:
+		}
+		lchan <- game.CurrentLevel.Snapshot(game.windows[lchan])
 	}
+	game.sendSpectators()
+}
 
-	for input := range game.InputChan {
-		if input.Input == Quit {
-			return 
+// autoStepDelay paces the turns players play without input, such as
+// exploring, so they can be followed on screen.
+const autoStepDelay = 50 * time.Millisecond
+
+func (game *Game) autoPlayer() *Player {
+	for _, player := range game.Players {
+		if player.auto != noAuto && !player.Dead && !player.away && !player.acted {
+			return player
 		}
+	}
+	return nil
+}
 
-		p := game.CurrentLevel.Player.Pos
-		game.CurrentLevel.bresenham(p, Pos{p.X+7,p.Y-3})
-		// for _, pos := range bres {
-		// 	game.Level.Debug[pos] = true
-		// }
+func (game *Game) Run() {
+	game.sendLevels()
 
-		game.handleInput(input)
+	for {
+		var input *Input
+		if player := game.autoPlayer(); player != nil {
+			select {
+			case input = <-game.InputChan:
+			case <-time.After(autoStepDelay):
+				game.autoStep(player)
+				if game.over {
+					panic("You died")
+				}
+				game.sendLevels()
+				continue
+			}
+		} else {
+			var ok bool
+			if input, ok = <-game.InputChan; !ok {
+				return
+			}
+		}
 
-		for _, monster := range game.CurrentLevel.Monsters {
-			monster.Update(game.CurrentLevel)
+		if input.Input == Quit {
+			return 
+		}
+		if input.Input == CloseWindow {
+			if spectator := game.spectatorFor(input.LevelChannel); spectator != nil {
+				game.RemoveSpectator(spectator)
+				continue
+			}
+			game.closeWindow(input.LevelChannel)
+			if len(game.LevelChan) == 0 {
+				return
+			}
+			continue
+		}
+		// Players who Leave keep their place in the world but no longer
+		// hold up the round until they Join again.
+		if input.Input == Join || input.Input == Leave {
+			if player := game.Player(input.Player); player != nil {
+				player.away = input.Input == Leave
+			}
+			if game.endRound() {
+				game.sendLevels()
+			}
+			continue
 		}
 
-		if len(game.LevelChan) == 0 {
-			return
+		// Each living player gets one action per round and the monsters
+		// move once everyone has had theirs.
+		player := game.Player(input.Player)
+		if player == nil || player.Dead || player.away || player.acted {
+			continue
 		}
 
-		for _, lchan := range game.LevelChan {
-			lchan <- game.CurrentLevel
+		// Anything the player does stops what they were doing on their own.
+		if game.startAuto(player, input) {
+			game.autoStep(player)
+		} else {
+			game.playTurn(player, input)
+		}
+		if game.over {
+			panic("You died")
 		}
+		game.sendLevels()
+	}
+}
+
+func (game *Game) playTurn(player *Player, input *Input) {
+	game.CurrentLevel.TurnEvents = nil
+	game.CurrentLevel.Log.Turn++
+	for _, hook := range game.hooks {
+		hook.OnTurnStart(game, input)
 	}
-}
\ No newline at end of file
+
+	player.acted = true
+	game.handleInput(player, input)
+
+	game.endRound()
+
+	for _, hook := range game.hooks {
+		hook.OnTurnEnd(game)
+	}
+}
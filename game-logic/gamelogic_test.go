@@ -0,0 +1,141 @@
+package game
+
+import (
+	"math/rand"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// The maps are loaded relative to the repository root.
+func TestMain(m *testing.M) {
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// readLevel touches everything a window draws from a snapshot.
+func readLevel(level *Level) int {
+	n := len(level.TurnEvents)
+	for _, row := range level.Map {
+		for _, tile := range row {
+			if tile.Visible || tile.Seen {
+				n++
+			}
+		}
+	}
+	for _, monster := range level.Monsters {
+		n += monster.Hp + len(monster.Items)
+	}
+	for _, items := range level.Items {
+		n += len(items)
+	}
+	for _, player := range level.Players {
+		n += player.Hp + player.X + player.Y
+		for _, item := range player.Items {
+			n += item.Count
+		}
+	}
+	for _, entry := range level.Log.Entries {
+		n += len(entry.String())
+	}
+	return n
+}
+
+// Run with -race: windows read the snapshots they are sent while Run goes
+// on playing turns.
+func TestSnapshotsWhileRunning(t *testing.T) {
+	game := newGame(1, rand.New(rand.NewSource(1)))
+	game.Players[0].Hp = 1 << 20
+	game.Players[0].MaxHp = 1 << 20
+
+	done := make(chan struct{})
+	read := make(chan int)
+	started := make(chan struct{})
+	go func() {
+		levels := 0
+		for {
+			select {
+			case level := <-game.LevelChan[0]:
+				readLevel(level)
+				if levels == 0 {
+					close(started)
+				}
+				levels++
+			case <-done:
+				read <- levels
+				return
+			}
+		}
+	}()
+	stopped := make(chan struct{})
+	go func() {
+		game.Run()
+		close(stopped)
+	}()
+
+	// Turns are only played once the reader is going, so it always has
+	// levels to read while they are.
+	<-started
+	moves := []InputState{Left, Down, Right, Up, Search, TakeAllItems, Explore, Rest}
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 300; i++ {
+		game.InputChan <- &Input{Input: moves[rng.Intn(len(moves))]}
+	}
+	game.InputChan <- &Input{Input: Quit}
+	<-stopped
+	close(done)
+	if levels := <-read; levels < 2 {
+		t.Error("no level was read while turns were played")
+	}
+}
+
+func TestClosingWindowEndsRound(t *testing.T) {
+	game := newGame(2, rand.New(rand.NewSource(1)))
+	first, second := game.Players[0], game.Players[1]
+	game.playTurn(first, &Input{Input: Search})
+	if !first.acted {
+		t.Fatal("round ended before the second player acted")
+	}
+
+	game.closeWindow(game.LevelChan[1])
+	if !second.away {
+		t.Error("player of the closed window still holds up the round")
+	}
+	if first.acted {
+		t.Error("round did not end when the window closed")
+	}
+	select {
+	case <-game.LevelChan[0]:
+	default:
+		t.Error("no level was sent to the open window")
+	}
+}
+
+// crowdedGame plays on a large generated level, where monsters keep
+// getting in each other's way.
+func crowdedGame() *Game {
+	level := newCrowdedLevel(96, 96)
+	game := &Game{CurrentLevel: level, Players: level.Players, turnCost: 1, watchers: &spectatorList{}, rng: rand.New(rand.NewSource(1))}
+	level.game = game
+	game.Players[0].Hp = 1 << 20
+	return game
+}
+
+func TestMonstersActInStableOrder(t *testing.T) {
+	first, second := crowdedGame(), crowdedGame()
+	for round := 0; round < 30; round++ {
+		first.playTurn(first.Players[0], &Input{Input: Search})
+		second.playTurn(second.Players[0], &Input{Input: Search})
+		a, b := first.CurrentLevel.Monsters, second.CurrentLevel.Monsters
+		if len(a) != len(b) {
+			t.Fatalf("round %d: %d and %d monsters left", round, len(a), len(b))
+		}
+		for pos, monster := range a {
+			if b[pos] == nil || !reflect.DeepEqual(monsterState(monster), monsterState(b[pos])) {
+				t.Fatalf("round %d: monsters differ at %v", round, pos)
+			}
+		}
+	}
+}
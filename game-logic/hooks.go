@@ -0,0 +1,65 @@
+package game
+
+// Hook lets code outside the package watch and extend a running Game.
+// Register one with Game.AddHook; embed BaseHook to implement only the
+// methods you need. Hooks run on the game goroutine, in the order they
+// were added, and may change the characters they are given.
+type Hook interface {
+	OnTurnStart(game *Game, input *Input)
+	OnMove(game *Game, character *Character, from, to Pos)
+	OnAttack(game *Game, attacker, defender *Character, damage int)
+	OnDeath(game *Game, character *Character)
+	OnItemPickup(game *Game, character *Character, item *Items)
+	OnLevelChange(game *Game, from, to *Level)
+	OnTurnEnd(game *Game)
+}
+
+type BaseHook struct{}
+
+func (BaseHook) OnTurnStart(game *Game, input *Input)                           {}
+func (BaseHook) OnMove(game *Game, character *Character, from, to Pos)          {}
+func (BaseHook) OnAttack(game *Game, attacker, defender *Character, damage int) {}
+func (BaseHook) OnDeath(game *Game, character *Character)                       {}
+func (BaseHook) OnItemPickup(game *Game, character *Character, item *Items)     {}
+func (BaseHook) OnLevelChange(game *Game, from, to *Level)                      {}
+func (BaseHook) OnTurnEnd(game *Game)                                           {}
+
+func (game *Game) AddHook(hook Hook) {
+	game.hooks = append(game.hooks, hook)
+}
+
+func (level *Level) onMove(character *Character, from, to Pos) {
+	if level.game == nil {
+		return
+	}
+	for _, hook := range level.game.hooks {
+		hook.OnMove(level.game, character, from, to)
+	}
+}
+
+func (level *Level) onAttack(attacker, defender *Character, damage int) {
+	if level.game == nil {
+		return
+	}
+	for _, hook := range level.game.hooks {
+		hook.OnAttack(level.game, attacker, defender, damage)
+	}
+}
+
+func (level *Level) onDeath(character *Character) {
+	if level.game == nil {
+		return
+	}
+	for _, hook := range level.game.hooks {
+		hook.OnDeath(level.game, character)
+	}
+}
+
+func (level *Level) onItemPickup(character *Character, item *Items) {
+	if level.game == nil {
+		return
+	}
+	for _, hook := range level.game.hooks {
+		hook.OnItemPickup(level.game, character, item)
+	}
+}
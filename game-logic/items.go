@@ -1,5 +1,10 @@
 package game
 
+import (
+	"strconv"
+	"sync/atomic"
+)
+
 type itemtype int
 
 const (
@@ -7,26 +12,137 @@ const (
 	Armour
 	Helmet
 	Potion
+	Key
+	Launcher
+	Ammo
+	Thrown
+	Spit
+	Scroll
 )
 
+const InventorySlots = 16
+
 type Items struct {
 	Type itemtype
 	Entity
 	Power float32
+	Count int
+	KeyID string
+	ID    int64
+	Range int
+	Ammo  string
+	Spell string
+}
+
+var lastItemID int64
+
+// newItemID gives every item a unique id, so inputs built from a snapshot
+// can be matched against the live item.
+func newItemID() int64 {
+	return atomic.AddInt64(&lastItemID, 1)
 }
 
 func NewSword(p Pos) *Items {
-	return &Items{Sword,Entity{p, 's',"Sword"},1.5}
+	return &Items{Sword,Entity{p, 's',"Sword"},1.5, 1, "", newItemID(), 0, "", ""}
 }
 
 func newHelmet(p Pos) *Items {
-	return &Items{Helmet,Entity{p, 'h', "Helmet"},.2}
+	return &Items{Helmet,Entity{p, 'h', "Helmet"},.2, 1, "", newItemID(), 0, "", ""}
 }
 
 func newArmour(p Pos) *Items {
-	return &Items{Armour,Entity{p, 'a', "Armour"},.3}
+	return &Items{Armour,Entity{p, 'a', "Armour"},.3, 1, "", newItemID(), 0, "", ""}
 }
 
 func newPotion(p Pos) *Items {
-	return &Items{Potion,Entity{p, 'p', "Potion"}, 50}
-}
\ No newline at end of file
+	return &Items{Potion,Entity{p, 'p', "Potion"}, 50, 1, "", newItemID(), 0, "", ""}
+}
+
+func newKey(p Pos, keyID string) *Items {
+	return &Items{Key,Entity{p, 'k', "Key"}, 0, 1, keyID, newItemID(), 0, "", ""}
+}
+
+func newBow(p Pos) *Items {
+	return &Items{Launcher,Entity{p, 'b', "Bow"}, 1.5, 1, "", newItemID(), 7, "Arrow", ""}
+}
+
+func newCrossbow(p Pos) *Items {
+	return &Items{Launcher,Entity{p, 'c', "Crossbow"}, 2.5, 1, "", newItemID(), 5, "Bolt", ""}
+}
+
+func newArrows(p Pos, count int) *Items {
+	return &Items{Ammo,Entity{p, 'r', "Arrow"}, 0, count, "", newItemID(), 0, "", ""}
+}
+
+func newBolts(p Pos, count int) *Items {
+	return &Items{Ammo,Entity{p, 'o', "Bolt"}, 0, count, "", newItemID(), 0, "", ""}
+}
+
+func newThrowingKnives(p Pos, count int) *Items {
+	return &Items{Thrown,Entity{p, 't', "Throwing knife"}, 1, count, "", newItemID(), 6, "", ""}
+}
+
+// newWebSpit is the spider's way of attacking from afar. It needs no ammo
+// and leaves a web where it lands.
+func newWebSpit(p Pos) *Items {
+	return &Items{Spit,Entity{p, 'w', "Web"}, .5, 1, "", newItemID(), 4, "", ""}
+}
+
+func newScroll(p Pos, spell string) *Items {
+	return &Items{Scroll,Entity{p, '?', spell + " scroll"}, 0, 1, "", newItemID(), 0, "", spell}
+}
+
+func (item *Items) Equippable() bool {
+	return item.Type == Sword || item.Type == Armour || item.Type == Helmet || item.Type == Launcher
+}
+
+func (item *Items) stackable() bool {
+	return item.Type == Potion || item.Type == Ammo || item.Type == Thrown || item.Type == Scroll
+}
+
+// Usable tells whether the item is used up by UseItem, like a potion.
+func (item *Items) Usable() bool {
+	return item.Type == Potion || item.Type == Scroll
+}
+
+func (c *Character) addItem(newItem *Items) bool {
+	if newItem.stackable() {
+		for _, item := range c.Items {
+			if item.Type == newItem.Type && item.Name == newItem.Name {
+				item.Count += newItem.Count
+				return true
+			}
+		}
+	}
+	if len(c.Items) >= InventorySlots {
+		return false
+	}
+	c.Items = append(c.Items, newItem)
+	return true
+}
+
+func (item *Items) Description() string {
+	switch item.Type {
+	case Sword:
+		return "Multiplies your attack by " + strconv.FormatFloat(float64(item.Power), 'f', 1, 32)
+	case Armour, Helmet:
+		return "Blocks " + strconv.Itoa(int(item.Power*100)) + "% of incoming damage"
+	case Potion:
+		return "Restores " + strconv.Itoa(int(item.Power)) + " HP when drunk"
+	case Launcher:
+		return "Fires " + item.Ammo + "s up to " + strconv.Itoa(item.Range) + " tiles, multiplying your attack by " + strconv.FormatFloat(float64(item.Power), 'f', 1, 32)
+	case Ammo:
+		return "Ammunition, fired from a weapon that takes " + item.Name + "s"
+	case Thrown:
+		return "Thrown up to " + strconv.Itoa(item.Range) + " tiles, multiplying your attack by " + strconv.FormatFloat(float64(item.Power), 'f', 1, 32)
+	case Scroll:
+		def := AbilityDefinition(item.Spell)
+		return "Teaches " + def.Name + ": " + def.Description + " for " + strconv.Itoa(def.Mana) + " mana"
+	case Key:
+		if item.KeyID != "" {
+			return "Opens the " + item.KeyID + " locked door"
+		}
+		return "Opens a locked door"
+	}
+	return ""
+}
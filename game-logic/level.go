@@ -2,13 +2,15 @@ package game
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/csv"
 	"math"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"time"
+	"sync"
 )
 
 type Tile struct {
@@ -25,24 +27,38 @@ const (
 	HellFloor = ','
 	ClosedDoor = '|'
 	OpenedDoor = '/'
+	LockedDoor = 'L'
 	Blank = 0
 	Pending = -1
 	Upstair = 'U'
 	Downstair = 'D'
 	Coin = 'C'
+	Water = '~'
+	Mud = ';'
+	Web = '"'
+	Lava = '='
+	Ice = '_'
+	Bridge = '+'
 )
 
 type Level struct {
 	Map       [][]Tile
-	Events    []string
-	EventPos  int
+	Log       *MessageLog
+	TurnEvents []Event
 	Player    *Player
+	Players   []*Player
 	Monsters  map[Pos]*Monster
 	Portals   map[Pos]*LevelPos
 	Items     map[Pos][]*Items
-	LastEvent GameEvent
+	Locks     map[Pos]string
+	Traps     map[Pos]*Trap
 	Debug     map[Pos]bool
-	Coins int
+	Diagonal bool
+	Spectator bool
+	start     Pos
+	views     map[int]*fieldOfView
+	distanceMaps map[distanceKey][][]int
+	game      *Game
 }
 
 type LevelPos struct {
@@ -55,11 +71,7 @@ type Pos struct {
 }
 
 func (game *Game) loadWorldFile() {
-	file, err := os.Open("game-logic/maps/world.txt")
-	if err != nil {
-		panic(err)
-	}
-	csvReaders := csv.NewReader(file)
+	csvReaders := csv.NewReader(bytes.NewReader(readGameFile("game-logic/maps/world.txt")))
 	csvReaders.FieldsPerRecord = -1
 	csvReaders.TrimLeadingSpace = true
 	rows, err := csvReaders.ReadAll()
@@ -106,133 +118,215 @@ func (game *Game) loadWorldFile() {
 	}
 }
 
-func loadLevels() map[string]*Level {
+// loadPropertiesFile reads the settings that apply to a whole level, such
+// as diagonal movement.
+func (game *Game) loadPropertiesFile() {
+	csvReaders := csv.NewReader(bytes.NewReader(readGameFile("game-logic/maps/properties.txt")))
+	csvReaders.FieldsPerRecord = -1
+	csvReaders.TrimLeadingSpace = true
+	rows, err := csvReaders.ReadAll()
+	if err != nil {
+		panic(err)
+	}
+	for _, row := range rows {
+		level := game.Levels[row[0]]
+		if level == nil {
+			panic("couldn't find the name of level in the properties file")
+		}
+		switch row[1] {
+		case "diagonal":
+			level.Diagonal = true
+		default:
+			panic("unknown property " + row[1] + " in the properties file")
+		}
+	}
+}
+
+// legendEntry is an extra map character defined in the legend of a map
+// file: a locked door or key with an id, or a hidden trap.
+type legendEntry struct {
+	Rune   rune
+	ID     string
+	Hidden bool
+}
+
+// parseLegend splits a map file into its grid and its legend. The legend
+// follows a "---" line, one character per line, such as "1 lock bronze"
+// for a locked door opened by the bronze key, "2 key bronze" for that key
+// and "3 hidden ^" for a hidden spike trap.
+func parseLegend(lines []string) ([]string, map[rune]legendEntry) {
+	legend := make(map[rune]legendEntry)
+	for i, line := range lines {
+		if line != "---" {
+			continue
+		}
+		for _, entry := range lines[i+1:] {
+			fields := strings.Fields(entry)
+			if len(fields) == 0 {
+				continue
+			}
+			if len(fields) != 3 || len([]rune(fields[0])) != 1 {
+				panic("invalid legend line in map: " + entry)
+			}
+			char := []rune(fields[0])[0]
+			switch fields[1] {
+			case "lock":
+				legend[char] = legendEntry{LockedDoor, fields[2], false}
+			case "key":
+				legend[char] = legendEntry{'k', fields[2], false}
+			case "hidden":
+				legend[char] = legendEntry{[]rune(fields[2])[0], "", true}
+			default:
+				panic("unknown legend kind " + fields[1] + " in map")
+			}
+		}
+		return lines[:i], legend
+	}
+	return lines, legend
+}
+
+func newPlayer(id int) *Player {
 	player := &Player{}
+	player.ID = id
 	player.Rune = '@'
 	player.Name = "Player"
+	if id > 0 {
+		player.Name += " " + strconv.Itoa(id+1)
+	}
 	player.Hp = 150
+	player.MaxHp = 150
+	player.Level = 1
 	player.Strength = 5
 	player.Speed = 1
 	player.Ap = 1
 	player.SightRange = 10
+	player.Mana = 30
+	player.MaxMana = 30
+	return player
+}
+
+var mapFiles = struct {
+	sync.Mutex
+	data map[string][]byte
+}{data: make(map[string][]byte)}
+
+// readGameFile reads a file under game-logic/maps only once, since games
+// are often started over and over without windows.
+func readGameFile(fileName string) []byte {
+	mapFiles.Lock()
+	defer mapFiles.Unlock()
+	data, exist := mapFiles.data[fileName]
+	if !exist {
+		var err error
+		data, err = os.ReadFile(fileName)
+		if err != nil {
+			panic(err)
+		}
+		mapFiles.data[fileName] = data
+	}
+	return data
+}
 
+func loadLevels(rng *rand.Rand) map[string]*Level {
 	levels := make(map[string]*Level, 0)
+	log := &MessageLog{}
 
 	filenames, err := filepath.Glob("game-logic/maps/*.map")
 	if err != nil {
 		panic(err)
 	}
 	for _, fileName := range filenames {
-		extensionIndex := strings.LastIndex(fileName, ".map")
-		lastSlashIndex := strings.LastIndex(fileName, "\\")
-		levelName := fileName[lastSlashIndex+1 : extensionIndex]
-
-		file, err := os.Open(fileName)
-
-		if err != nil {
-			panic(err)
-		}
+		levelName := strings.TrimSuffix(filepath.Base(fileName), ".map")
+		level := newLevel(readMapFile(fileName), log, rng)
+		levels[levelName] = level
+	}
 
-		defer file.Close()
+	return levels
+}
 
-		scanner := bufio.NewScanner(file)
+func readMapFile(fileName string) []string {
+	lines := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(readGameFile(fileName)))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
 
-		temp := make([]string, 0)
-		longest, index := 0, 0
+func newLevel(temp []string, log *MessageLog, rng *rand.Rand) *Level {
+	temp, legend := parseLegend(temp)
+	longest := 0
+	for _, line := range temp {
+		if longest < len(line) {
+			longest = len(line)
+		}
+	}
 
-		for scanner.Scan() {
-			temp = append(temp, scanner.Text())
-			if longest < len(temp[index]) {
-				longest = len(temp[index])
+	level := &Level{Map: make([][]Tile, len(temp)), Monsters: make(map[Pos]*Monster)}
+
+	level.Debug = make(map[Pos]bool)
+	level.Log = log
+	level.views = make(map[int]*fieldOfView)
+	level.Portals = make(map[Pos]*LevelPos)
+	level.Items = make(map[Pos][]*Items)
+	level.Locks = make(map[Pos]string)
+	level.Traps = make(map[Pos]*Trap)
+
+	for y := 0; y < len(level.Map); y++ {
+		level.Map[y] = make([]Tile, longest)
+		for x, col := range temp[y] {
+			entry, inLegend := legend[col]
+			if inLegend {
+				col = entry.Rune
 			}
-			index++
-		}
-
-		level := &Level{Map: make([][]Tile, len(temp)), Monsters: make(map[Pos]*Monster), Events: make([]string, 12)}
-
-		level.Debug = make(map[Pos]bool)
-		level.EventPos = 0
-		level.Player = player
-		level.Portals = make(map[Pos]*LevelPos)
-		level.Items = make(map[Pos][]*Items)
-
-		for y := 0; y < len(level.Map); y++ {
-			level.Map[y] = make([]Tile, longest)
-			for x, col := range temp[y] {
-				var t Tile
-				t.OverlayRune = Blank
-				if col == ' ' || col == '\t' || col == '\n' || col == '\r' {
-					t.Rune = Blank
-				} else if col == '#' {
-					t.Rune = StoneWall
-				} else if col == '&' {
-					t.Rune = HellWall
-				} else if col == '.' {
-					t.Rune = DirtFloor
-				} else if col == ',' {
-					t.Rune = HellFloor
-				} else if col == '|' {
-					t.OverlayRune = ClosedDoor
-					t.Rune = Pending
-				} else if col == '/' {
+			var t Tile
+			t.OverlayRune = Blank
+			if col == ' ' || col == '\t' || col == '\n' || col == '\r' {
+				t.Rune = Blank
+			} else if def, exist := tileDefs[col]; exist && def.Spawn != nil {
+				t.Rune = Pending
+				def.Spawn(level, def, Pos{x, y}, rng)
+			} else if def, exist := tileDefs[col]; exist {
+				if def.Overlay {
 					t.Rune = Pending
-					t.OverlayRune = OpenedDoor
-				} else if col == '@' {
-					t.Rune = Pending
-					if level.Player.Pos.X == 0 && level.Player.Pos.Y == 0 {
-						level.Player.Pos.X = x
-						level.Player.Pos.Y = y
-					}
-				} else if col == 'G' {
-					t.Rune = Pending
-					level.Monsters[Pos{x, y}] = NewGhost(Pos{x, y})
-				} else if col == 'R' {
-					t.Rune = Pending
-					level.Monsters[Pos{x, y}] = NewRat(Pos{x, y})
-				} else if col == 'S' {
-					t.Rune = Pending
-					level.Monsters[Pos{x, y}] = NewSpider(Pos{x, y})
-				} else if col == 's' {
-					t.Rune = Pending
-					level.Items[Pos{x, y}] = append(level.Items[Pos{x, y}], NewSword(Pos{x, y}))
-				} else if col == 'h' {
-					t.Rune = Pending
-					level.Items[Pos{x, y}] = append(level.Items[Pos{x, y}], newHelmet(Pos{x, y}))
-				} else if col == 'a' {
-					t.Rune = Pending
-					level.Items[Pos{x, y}] = append(level.Items[Pos{x, y}], newArmour(Pos{x, y}))
-				} else if col == 'p' {
-					t.Rune = Pending
-					level.Items[Pos{x, y}] = append(level.Items[Pos{x, y}], newPotion(Pos{x, y}))
-				} else if col == 'D' {
-					t.Rune = Pending
-					t.OverlayRune = Downstair
-				} else if col == 'U' {
-					t.Rune = Pending
-					t.OverlayRune = Upstair
-				} else if col == 'C' {
-					t.Rune = Pending
-					t.OverlayRune = Coin
+					t.OverlayRune = def.Rune
 				} else {
-					panic("the character that you put in map is invalid")
+					t.Rune = def.Rune
+				}
+				if def.Locked {
+					level.Locks[Pos{x, y}] = ""
 				}
-				level.Map[y][x] = t
+			} else {
+				panic("the character that you put in map is invalid")
+			}
+			if inLegend {
+				level.applyLegend(Pos{x, y}, entry)
 			}
+			level.Map[y][x] = t
 		}
+	}
 
-		for y, row := range level.Map {
-			for x, col := range row {
-				if col.Rune == Pending {
-					level.Map[y][x].Rune = level.bfsFloor(Pos{x, y})
-				}
+	level.bfsFloor()
+	return level
+}
+
+func (level *Level) applyLegend(pos Pos, entry legendEntry) {
+	switch {
+	case entry.Rune == LockedDoor:
+		level.Locks[pos] = entry.ID
+	case entry.Rune == 'k':
+		for _, item := range level.Items[pos] {
+			if item.Type == Key {
+				item.KeyID = entry.ID
 			}
 		}
-
-		level.lineOfSight()
-		levels[levelName] = level
+	case entry.Hidden:
+		if trap := level.Traps[pos]; trap != nil {
+			trap.Hidden = true
+		} else {
+			panic("a hidden legend entry in map is not a trap")
+		}
 	}
-
-	return levels
 }
 
 func getNeighbour(level *Level, pos Pos) []Pos {
@@ -253,31 +347,64 @@ func getNeighbour(level *Level, pos Pos) []Pos {
 	if canWalk(level, pos.X, pos.Y+1) {
 		res = append(res, down)
 	}
+	if level.Diagonal {
+		for _, diagonal := range []Pos{{pos.X - 1, pos.Y - 1}, {pos.X + 1, pos.Y - 1}, {pos.X - 1, pos.Y + 1}, {pos.X + 1, pos.Y + 1}} {
+			if canWalk(level, diagonal.X, diagonal.Y) && canCutCorner(level, pos, diagonal) {
+				res = append(res, diagonal)
+			}
+		}
+	}
 	return res
 }
 
-func (level *Level) bfsFloor(pos Pos) rune {
-	queue := make([]Pos, 0, 8)
-	visited := make(map[Pos]bool)
-	queue = append(queue, pos)
-	visited[pos] = true
+// bfsFloor fills every Pending tile (the ground under doors, entities and
+// items) with the nearest floor it can walk to, in one flood fill seeded
+// from all floors. Like a walk, the fill crosses water, mud and the like
+// but stops at walls and closed doors. Pending tiles no floor can reach
+// become dirt.
+func (level *Level) bfsFloor() {
+	type step struct {
+		pos   Pos
+		floor rune
+	}
+	visited := make([][]bool, len(level.Map))
+	queue := make([]step, 0, 64)
+	for y, row := range level.Map {
+		visited[y] = make([]bool, len(row))
+		for x, tile := range row {
+			if TileDefinition(tile.Rune).Floor {
+				visited[y][x] = true
+				queue = append(queue, step{Pos{x, y}, tile.Rune})
+			}
+		}
+	}
 
 	for len(queue) > 0 {
 		curr := queue[0]
-		currTile := level.Map[curr.Y][curr.X]
-		if currTile.Rune == DirtFloor || currTile.Rune == HellFloor {
-			return currTile.Rune
-		}
 		queue = queue[1:]
-		for _, adj := range getNeighbour(level, curr) {
-			if !visited[adj] && canWalk(level, adj.X, adj.Y) {
-				queue = append(queue, adj)
-				visited[adj] = true
-				time.Sleep(100 * time.Millisecond)
+		tile := &level.Map[curr.pos.Y][curr.pos.X]
+		if tile.Rune == Pending {
+			tile.Rune = curr.floor
+		}
+		if !tile.walkable() {
+			continue
+		}
+		adjs := []Pos{{curr.pos.X, curr.pos.Y - 1}, {curr.pos.X - 1, curr.pos.Y}, {curr.pos.X + 1, curr.pos.Y}, {curr.pos.X, curr.pos.Y + 1}}
+		for _, adj := range adjs {
+			if level.inBounds(adj.X, adj.Y) && !visited[adj.Y][adj.X] && TileDefinition(level.Map[adj.Y][adj.X].Rune).Walkable {
+				visited[adj.Y][adj.X] = true
+				queue = append(queue, step{adj, curr.floor})
+			}
+		}
+	}
+
+	for y, row := range level.Map {
+		for x, tile := range row {
+			if tile.Rune == Pending {
+				level.Map[y][x].Rune = DirtFloor
 			}
 		}
 	}
-	return DirtFloor
 }
 
 func (level *Level) aStar(start, goal Pos) []Pos {
@@ -313,13 +440,14 @@ func (level *Level) aStar(start, goal Pos) []Pos {
 		}
 
 		for _, next := range getNeighbour(level, curr) {
-			newCost := costSoFar[curr] + 1
+			if level.isKnownTrap(next) && next != goal {
+				continue
+			}
+			newCost := costSoFar[curr] + movementCost(level, next)
 			_, exist := costSoFar[next]
 			if !exist || newCost < costSoFar[next] {
 				costSoFar[next] = newCost
-				xDist := int(math.Abs(float64(goal.X - next.X)))
-				yDist := int(math.Abs(float64(goal.Y - next.Y)))
-				priority := newCost + xDist + yDist
+				priority := newCost + level.heuristic(next, goal)
 				pq = pq.push(next, priority)
 				cameFrom[next] = curr
 				// level.Debug[next] = true
@@ -331,10 +459,58 @@ func (level *Level) aStar(start, goal Pos) []Pos {
 	return nil
 }
 
-func (level *Level) lineOfSight() {
-	
-	pos := level.Player.Pos
-	dist := level.Player.SightRange
+// heuristic never overestimates since every step costs at least 1: the
+// Manhattan distance for four directions, the Chebyshev distance for eight.
+func (level *Level) heuristic(from, to Pos) int {
+	xDist := int(math.Abs(float64(to.X - from.X)))
+	yDist := int(math.Abs(float64(to.Y - from.Y)))
+	if level.Diagonal {
+		if xDist > yDist {
+			return xDist
+		}
+		return yDist
+	}
+	return xDist + yDist
+}
+
+// fieldOfView is what one player can see now and has seen so far on a
+// level.
+type fieldOfView struct {
+	visible [][]bool
+	seen    [][]bool
+}
+
+func (level *Level) view(player *Player) *fieldOfView {
+	view, exist := level.views[player.ID]
+	if !exist {
+		view = &fieldOfView{make([][]bool, len(level.Map)), make([][]bool, len(level.Map))}
+		for y := range level.Map {
+			view.visible[y] = make([]bool, len(level.Map[y]))
+			view.seen[y] = make([]bool, len(level.Map[y]))
+		}
+		level.views[player.ID] = view
+	}
+	return view
+}
+
+func (level *Level) lineOfSight(player *Player) {
+	view := level.view(player)
+	for y := range view.visible {
+		for x := range view.visible[y] {
+			view.visible[y][x] = false
+		}
+	}
+
+	see := func(pos Pos) bool {
+		if !level.inBounds(pos.X, pos.Y) {
+			return false
+		}
+		view.visible[pos.Y][pos.X] = true
+		view.seen[pos.Y][pos.X] = true
+		return canSee(level, pos.X, pos.Y)
+	}
+	pos := player.Pos
+	dist := player.SightRange
 
 	for y := pos.Y - dist; y <= pos.Y+dist; y++ {
 		for x := pos.X - dist; x <= pos.X+dist; x++ {
@@ -342,74 +518,84 @@ func (level *Level) lineOfSight() {
 			yDelta := pos.Y - y
 			d := math.Sqrt(float64(xDelta*xDelta + yDelta*yDelta))
 			if d <= float64(dist) {
-				level.bresenham(pos, Pos{x, y})
+				bresenham(pos, Pos{x, y}, see)
 			}
 		}
 	}
 }
 
-func (level *Level) bresenham(start, end Pos) {
+// updateSight recomputes every player's field of view after the map
+// itself changed, such as a door opening.
+func (level *Level) updateSight() {
+	for _, player := range level.alivePlayers() {
+		level.lineOfSight(player)
+	}
+}
+
+// bresenham walks the line from start to end, both included, calling visit
+// for each tile until it returns false.
+func bresenham(start, end Pos, visit func(Pos) bool) {
 	isSteep := math.Abs(float64(end.Y-start.Y)) > math.Abs(float64(end.X-start.X))
 	if isSteep {
 		start.X, start.Y = start.Y, start.X
 		end.X, end.Y = end.Y, end.X
 	}
 	deltaY := int(math.Abs(float64(end.Y - start.Y)))
+	deltaX := int(math.Abs(float64(end.X - start.X)))
 	err := 0
 	y := start.Y
 	yStep := 1
 	if start.Y >= end.Y {
 		yStep = -1
 	}
+	xStep := 1
 	if start.X > end.X {
-		deltaX := start.X - end.X
-		for x := start.X; x >= end.X; x-- {
-			var pos Pos
-			if isSteep {
-				pos = Pos{y, x}
-			} else {
-				pos = Pos{x, y}
-			}
-			level.Map[pos.Y][pos.X].Visible = true
-			level.Map[pos.Y][pos.X].Seen = true
-			if !canSee(level, pos.X, pos.Y) {
-				return
-			}
-			err += deltaY
-			if 2*err >= deltaX {
-				y += yStep
-				err -= deltaX
-			}
+		xStep = -1
+	}
+	for x := start.X; ; x += xStep {
+		pos := Pos{x, y}
+		if isSteep {
+			pos = Pos{y, x}
 		}
-	} else {
-		deltaX := end.X - start.X
-		for x := start.X; x < end.X; x++ {
-			var pos Pos
-			if isSteep {
-				pos = Pos{y, x}
-			} else {
-				pos = Pos{x, y}
-			}
-			level.Map[pos.Y][pos.X].Visible = true
-			level.Map[pos.Y][pos.X].Seen = true
-			if !canSee(level, pos.X, pos.Y) {
-				return
-			}
-			err += deltaY
-			if 2*err >= deltaX {
-				y += yStep
-				err -= deltaX
-			}
+		if !visit(pos) || x == end.X {
+			return
+		}
+		err += deltaY
+		if 2*err >= deltaX {
+			y += yStep
+			err -= deltaX
 		}
 	}
 }
 
-func (level *Level) addEvent(s string) {
-	if level.EventPos == len(level.Events) {
-		level.Events = level.Events[1:]
-		level.Events = append(level.Events, s)
-	} else {
-		level.Events[level.EventPos] = s
-		level.EventPos++
+// placeParty puts the living players on pos, spreading them out over the
+// nearest free tiles when there is more than one.
+func (level *Level) placeParty(players []*Player, pos Pos) {
+	queue := []Pos{pos}
+	visited := map[Pos]bool{pos: true}
+	for _, player := range players {
+		if player.Dead {
+			continue
+		}
+		for len(queue) > 0 {
+			curr := queue[0]
+			queue = queue[1:]
+			for _, next := range getNeighbour(level, curr) {
+				if !visited[next] {
+					visited[next] = true
+					queue = append(queue, next)
+				}
+			}
+			if curr == pos || (canWalk(level, curr.X, curr.Y) && level.Traps[curr] == nil) {
+				player.Pos = curr
+				break
+			}
+		}
 	}
-}
\ No newline at end of file
+	level.invalidateDistanceMaps()
+	for _, player := range players {
+		if !player.Dead {
+			level.lineOfSight(player)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package game
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// generateMap builds a walled w by h map of hell floor crossed by water,
+// with a monster or item on roughly one tile in ten.
+func generateMap(w, h int, rng *rand.Rand) []string {
+	entities := []byte("RSGshap")
+	lines := make([]string, h)
+	for y := 0; y < h; y++ {
+		var line strings.Builder
+		for x := 0; x < w; x++ {
+			switch {
+			case x == 0 || y == 0 || x == w-1 || y == h-1:
+				line.WriteByte('&')
+			case x == 1 && y == 1:
+				line.WriteByte('@')
+			case y%16 == 8:
+				line.WriteByte('~')
+			case rng.Intn(10) == 0:
+				line.WriteByte(entities[rng.Intn(len(entities))])
+			default:
+				line.WriteByte(',')
+			}
+		}
+		lines[y] = line.String()
+	}
+	return lines
+}
+
+func TestBfsFloor(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	tests := []struct {
+		name  string
+		lines []string
+		want  rune
+	}{
+		{"next to floor", []string{"&&&&", "&,R&", "&&&&"}, HellFloor},
+		{"across water", []string{"&&&&&", "&,~R&", "&&&&&"}, HellFloor},
+		{"under a door", []string{"&&&&", "&,|&", "&&&&"}, HellFloor},
+		{"behind a wall", []string{"&&&&&", "&,&R&", "&&&&&"}, DirtFloor},
+		{"behind a closed door", []string{"&&&&&&", "&,|~R&", "&&&&&&"}, DirtFloor},
+	}
+	for _, test := range tests {
+		level := newLevel(test.lines, &MessageLog{}, rng)
+		x := len(test.lines[1]) - 2
+		if got := level.Map[1][x].Rune; got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func BenchmarkLoadLevel(b *testing.B) {
+	lines := generateMap(512, 512, rand.New(rand.NewSource(1)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		newLevel(lines, &MessageLog{}, rand.New(rand.NewSource(1)))
+	}
+}
@@ -0,0 +1,94 @@
+package game
+
+import "strconv"
+
+// unlockDoor opens the locked door at pos if the character has its key.
+// Keys are kept, so one key opens every door with its id, as
+// validateLocks expects.
+func (level *Level) unlockDoor(pos Pos, character *Character) {
+	id := level.Locks[pos]
+	for _, item := range character.Items {
+		if item.Type == Key && item.KeyID == id {
+			delete(level.Locks, pos)
+			level.Map[pos.Y][pos.X].OverlayRune = TileDefinition(LockedDoor).OpensTo
+			level.invalidateDistanceMaps()
+			level.updateSight()
+			level.addEvent(Event{Kind: UnlockDoor, Actor: character.Name, Pos: pos})
+			return
+		}
+	}
+	level.addEvent(Event{Kind: DoorLocked, Actor: character.Name, Pos: pos})
+}
+
+func (level *Level) canPassWithKeys(pos Pos, keys map[string]bool) bool {
+	if !level.inBounds(pos.X, pos.Y) {
+		return false
+	}
+	tile := level.Map[pos.Y][pos.X]
+	if !TileDefinition(tile.Rune).Walkable {
+		return false
+	}
+	if tile.OverlayRune != Blank && TileDefinition(tile.OverlayRune).Locked {
+		return keys[level.Locks[pos]]
+	}
+	return true
+}
+
+// reachableKeys walks the level from its entrances, opening every locked
+// door whose key has already been found, until no new key turns up.
+func (level *Level) reachableKeys(entrances []Pos) map[string]bool {
+	keys := make(map[string]bool)
+	for {
+		found := false
+		visited := make(map[Pos]bool)
+		queue := make([]Pos, 0, 8)
+		for _, pos := range entrances {
+			if level.canPassWithKeys(pos, keys) && !visited[pos] {
+				queue = append(queue, pos)
+				visited[pos] = true
+			}
+		}
+		for len(queue) > 0 {
+			curr := queue[0]
+			queue = queue[1:]
+			for _, item := range level.Items[curr] {
+				if item.Type == Key && !keys[item.KeyID] {
+					keys[item.KeyID] = true
+					found = true
+				}
+			}
+			adjs := []Pos{{curr.X, curr.Y - 1}, {curr.X - 1, curr.Y}, {curr.X + 1, curr.Y}, {curr.X, curr.Y + 1}}
+			for _, adj := range adjs {
+				if !visited[adj] && level.canPassWithKeys(adj, keys) {
+					queue = append(queue, adj)
+					visited[adj] = true
+				}
+			}
+		}
+		if !found {
+			return keys
+		}
+	}
+}
+
+func (game *Game) validateLocks() {
+	entrances := make(map[*Level][]Pos)
+	entrances[game.CurrentLevel] = append(entrances[game.CurrentLevel], game.CurrentLevel.start)
+	for _, level := range game.Levels {
+		for _, levelPos := range level.Portals {
+			entrances[levelPos.Level] = append(entrances[levelPos.Level], levelPos.Pos)
+		}
+	}
+
+	for name, level := range game.Levels {
+		if len(level.Locks) == 0 {
+			continue
+		}
+		keys := level.reachableKeys(entrances[level])
+		for pos, id := range level.Locks {
+			if !keys[id] {
+				panic("the locked door at " + strconv.Itoa(pos.X) + "," + strconv.Itoa(pos.Y) + " in " + name + " has no reachable key")
+			}
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestKeyOpensEveryDoorWithItsID(t *testing.T) {
+	level := newLevel([]string{"&&&&&&&", "&2,1,1&", "&&&&&&&", "---", "1 lock gold", "2 key gold"}, &MessageLog{}, rand.New(rand.NewSource(1)))
+	player := newPlayer(0)
+	player.Pos = Pos{2, 1}
+	player.Items = level.Items[Pos{1, 1}]
+	level.Players = []*Player{player}
+	if keys := level.reachableKeys([]Pos{{1, 1}}); !keys["gold"] {
+		t.Fatal("the gold key is not reachable")
+	}
+
+	for _, door := range []Pos{{3, 1}, {5, 1}} {
+		level.unlockDoor(door, &player.Character)
+		if level.Map[door.Y][door.X].OverlayRune != OpenedDoor {
+			t.Errorf("door at %v is still locked", door)
+		}
+	}
+	if len(level.Locks) != 0 || len(player.Items) != 1 {
+		t.Errorf("%d locks left and %d items held, want none left and the key kept", len(level.Locks), len(player.Items))
+	}
+}
@@ -2,33 +2,35 @@ package game
 
 import (
 	"math/rand"
-	"time"
 )
 
 type Monster struct {
 	Character
+	Experience int
+	OpensDoors bool
+	UsesItems  bool
+	afraid     int
 }
 
-func NewRat(pos Pos) *Monster {
-	items := getItemDropped(pos)
-	return &Monster{Character{Entity{pos, 'R', "Rat"}, 10, 3, 1, 0, 10, items, nil, nil, nil}}
+func NewRat(pos Pos, rng *rand.Rand) *Monster {
+	items := getItemDropped(pos, rng)
+	return &Monster{Character{Entity{pos, 'R', "Rat"}, 10, 10, 3, 1, 0, 10, items, nil, nil, nil, nil, 0, 0, 0, 0, nil}, 10, false, false, 0}
 }
 
-func NewSpider(pos Pos) *Monster {
+func NewSpider(pos Pos, rng *rand.Rand) *Monster {
 	// dropped item
-	items := getItemDropped(pos)
-	return &Monster{Character{Entity{pos, 'S', "Spider"}, 15, 5, 1, 0, 10, items, nil, nil, nil}}
+	items := getItemDropped(pos, rng)
+	return &Monster{Character{Entity{pos, 'S', "Spider"}, 15, 15, 5, 1, 0, 10, items, nil, nil, nil, newWebSpit(pos), 0, 0, 0, 0, nil}, 20, false, false, 0}
 }
 
-func NewGhost(pos Pos) *Monster {
-	items := getItemDropped(pos)
-	return &Monster{Character{Entity{pos, 'G', "Ghost"}, 20, 10, 1, 0, 10, items, nil, nil, nil}}
+func NewGhost(pos Pos, rng *rand.Rand) *Monster {
+	items := getItemDropped(pos, rng)
+	return &Monster{Character{Entity{pos, 'G', "Ghost"}, 20, 20, 10, 1, 0, 10, items, nil, nil, nil, nil, 0, 0, 20, 20, []Ability{{"Firebolt", 0}, {"Heal", 0}}}, 40, true, true, 0}
 }
 
-func getItemDropped(pos Pos) []*Items {
+func getItemDropped(pos Pos, rng *rand.Rand) []*Items {
 	items := make([]*Items, 0)
-	rand.Seed(time.Now().UnixNano())
-	r := rand.Intn(15)
+	r := rng.Intn(15)
 	switch r {
 	case 1:
 		items = append(items, newArmour(pos))
@@ -42,53 +44,168 @@ func getItemDropped(pos Pos) []*Items {
 		items = append(items, NewSword(pos), newArmour(pos))
 	case 6:
 		items = append(items, newHelmet(pos), newPotion(pos))
+	case 7:
+		items = append(items, newBow(pos), newArrows(pos, 10))
+	case 8:
+		items = append(items, newArrows(pos, 5))
+	case 9:
+		items = append(items, newThrowingKnives(pos, 3))
+	case 10:
+		items = append(items, newCrossbow(pos), newBolts(pos, 6))
+	case 11:
+		items = append(items, newScroll(pos, abilityNames[rng.Intn(len(abilityNames))]))
 	}
 	return items
 }
 
 func (m *Monster) Update(level *Level) {
 	m.Ap += m.Speed
-	playerPos := level.Player.Pos
-	path := level.aStar(m.Pos, playerPos)
-	if len(path) == 0 {
+	if m.UsesItems && m.useItems(level) {
+		m.Ap--
+		return
+	}
+	if m.afraid == 0 && m.castAbility(level) {
+		return
+	}
+	if m.afraid == 0 && m.Ranged != nil && m.shoot(level) {
+		return
+	}
+	dist := level.getDistanceMap(m.Hp < m.MaxHp/4 || m.afraid > 0, m.OpensDoors)
+	if dist[m.Y][m.X] == unreachable {
 		m.pass()
 		return
 	}
-	apInt := int(m.Ap)
-	for i := 1; i <= apInt; i++ {
-		if i < len(path) {
-			m.Move(path[i], level)
+	// A monster with nowhere better to go, blocked by others or cornered
+	// while fleeing, waits instead of saving up its turns.
+	for moved := false; ; moved = true {
+		next, ok := level.downhill(dist, m.Pos, m.OpensDoors)
+		if !ok {
+			if !moved {
+				m.pass()
+			}
+			return
+		}
+		if isClosedDoor(level, next.X, next.Y) {
+			if m.Ap < 1 {
+				return
+			}
+			m.openDoor(next, level)
 			m.Ap--
+			return
+		}
+		cost := float64(movementCost(level, next))
+		if m.Ap < cost {
+			return
+		}
+		m.Move(next, level)
+		m.Ap -= cost
+		if level.Monsters[m.Pos] != m || m.Pos != next {
+			return
 		}
 	}
 }
 
+func (m *Monster) openDoor(pos Pos, level *Level) {
+	level.Map[pos.Y][pos.X].OverlayRune = TileDefinition(level.Map[pos.Y][pos.X].OverlayRune).OpensTo
+	level.invalidateDistanceMaps()
+	level.updateSight()
+	level.addEvent(Event{Kind: OpenDoor, Actor: m.Name, Pos: pos})
+}
+
+// useItems lets the monster drink a potion when wounded or pick up a
+// weapon, armour or potion it is standing on. It reports whether the
+// monster spent its turn.
+func (m *Monster) useItems(level *Level) bool {
+	if m.Hp < m.MaxHp/2 {
+		for _, item := range m.Items {
+			if item.Type == Potion {
+				level.UseItem(item, &m.Character)
+				return true
+			}
+		}
+	}
+
+	items := level.Items[m.Pos]
+	for i, item := range items {
+		if !monsterUsable(item) {
+			continue
+		}
+		level.Items[m.Pos] = append(items[:i:i], items[i+1:]...)
+		if !m.equipIfBetter(item) {
+			m.Items = append(m.Items, item)
+		}
+		level.addEvent(Event{Kind: PickUpItems, Actor: m.Name, Target: item.Name, Pos: m.Pos})
+		level.onItemPickup(&m.Character, item)
+		return true
+	}
+	return false
+}
+
+func monsterUsable(item *Items) bool {
+	switch item.Type {
+	case Sword, Armour, Helmet, Potion:
+		return true
+	}
+	return false
+}
+
+func (m *Monster) equipIfBetter(item *Items) bool {
+	var slot **Items
+	switch item.Type {
+	case Sword:
+		slot = &m.Sword
+	case Armour:
+		slot = &m.Armour
+	case Helmet:
+		slot = &m.Helmet
+	default:
+		return false
+	}
+	if *slot != nil && (*slot).Power >= item.Power {
+		return false
+	}
+	if *slot != nil {
+		m.Items = append(m.Items, *slot)
+	}
+	*slot = item
+	return true
+}
+
 func (m *Monster) pass() {
 	m.Ap -= m.Speed
 }
 
 func (m *Monster) Move(to Pos, level *Level) {
 	_, exist := level.Monsters[to]
-	if !exist && to != level.Player.Pos {
+	player := level.playerAt(to)
+	if !exist && player == nil {
+		from := m.Pos
 		delete(level.Monsters, m.Pos)
 		level.Monsters[to] = m
 		m.Pos = to
-	} else if to == level.Player.Pos {
-		level.Attack(&m.Character, &level.Player.Character)
-		level.addEvent(m.Name + "attack player")
+		level.onMove(&m.Character, from, to)
+		level.triggerTrap(&m.Character)
 		if m.Hp <= 0 {
+			level.addEvent(Event{Kind: MonsterDeath, Target: m.Name, Pos: m.Pos})
 			m.Dead(level)
 		}
-		if level.Player.Hp <= 0 {
-			panic("You died!")
+	} else if player != nil {
+		level.Attack(&m.Character, &player.Character)
+		if player.Hp <= 0 {
+			level.killPlayer(player)
 		}
 	}
 }
 
 func (m *Monster) Dead(level *Level) {
-	level.addEvent("Player killed" + m.Name)
+	level.onDeath(&m.Character)
 	delete(level.Monsters, m.Pos)
 	groundItems := level.Items[m.Pos]
+	for _, item := range []*Items{m.Sword, m.Armour, m.Helmet, m.Ranged} {
+		if item != nil && item.Type != Spit {
+			m.Items = append(m.Items, item)
+		}
+	}
 	for _, item := range m.Items {
 		item.Pos = m.Pos
 		groundItems = append(groundItems, item)
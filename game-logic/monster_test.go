@@ -0,0 +1,43 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMonsterLeavesItemsItCannotUse(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	level := newLevel([]string{"&&&", "&G&", "&&&"}, &MessageLog{}, rng)
+	pos := Pos{1, 1}
+	ghost := level.Monsters[pos]
+	ghost.Items = nil
+	key, sword := newKey(pos, "bronze"), NewSword(pos)
+	level.Items[pos] = []*Items{key, sword}
+
+	if !ghost.useItems(level) {
+		t.Fatal("ghost did not pick up the sword")
+	}
+	if ghost.Sword != sword {
+		t.Errorf("ghost wields %v, want the sword", ghost.Sword)
+	}
+	if ghost.useItems(level) {
+		t.Error("ghost picked up the key")
+	}
+	if items := level.Items[pos]; len(items) != 1 || items[0] != key {
+		t.Errorf("items left on the floor = %v, want only the key", items)
+	}
+}
+
+func TestBlockedMonsterDoesNotSaveUpTurns(t *testing.T) {
+	level := newLevel([]string{"&&&&&&", "&@RR,&", "&&&&&&"}, &MessageLog{}, rand.New(rand.NewSource(1)))
+	player := newPlayer(0)
+	player.Pos = level.start
+	level.Players = []*Player{player}
+	blocked := level.Monsters[Pos{3, 1}]
+	for i := 0; i < 10; i++ {
+		blocked.Update(level)
+	}
+	if blocked.Pos != (Pos{3, 1}) || blocked.Ap > blocked.Speed {
+		t.Errorf("blocked rat is at %v with %v AP, want it waiting at {3 1} with at most %v", blocked.Pos, blocked.Ap, blocked.Speed)
+	}
+}
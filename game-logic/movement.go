@@ -15,21 +15,38 @@ const (
 	TakeItem
 	DropItem
 	EquipItem
+	UseItem
+	AssignQuickSlot
+	UpLeft
+	UpRight
+	DownLeft
+	DownRight
+	Join
+	Leave
+	Explore
+	Travel
+	TravelToStairs
+	TravelToCoin
+	Rest
+	Fire
+	Cast
 )
 
 type Input struct {
 	Input        InputState
+	Player       int
 	Item         *Items
+	Slot         int
+	Target       Pos
 	LevelChannel chan *Level
 }
 
 func (level *Level) DropItem(itemToDrop *Items, character *Character) {
-	level.LastEvent = DropItems
-	level.addEvent("You dropped " + itemToDrop.Name)
 	pos := character.Pos
 	items := character.Items
 	for i, item := range items {
 		if item == itemToDrop {
+			level.addEvent(Event{Kind: DropItems, Actor: character.Name, Target: item.Name, Pos: pos})
 			character.Items = append(character.Items[:i], character.Items[i+1:]...)
 			level.Items[pos] = append(level.Items[pos], item)
 			return
@@ -37,16 +54,38 @@ func (level *Level) DropItem(itemToDrop *Items, character *Character) {
 	}
 }
 
+// findItem returns the live item in the player's inventory or under the
+// player with the same id as item, which may come from a snapshot.
+func (level *Level) findItem(player *Player, item *Items) *Items {
+	if item == nil {
+		return nil
+	}
+	for _, candidate := range player.Items {
+		if candidate.ID == item.ID {
+			return candidate
+		}
+	}
+	for _, candidate := range level.Items[player.Pos] {
+		if candidate.ID == item.ID {
+			return candidate
+		}
+	}
+	return nil
+}
+
 func (level *Level) MoveItem(itemToMove *Items, character *Character) {
-	level.LastEvent = PickUpItems
-	level.addEvent("You picked " + itemToMove.Name)
 	pos := character.Pos
 	items := level.Items[pos]
 	for i, item := range items {
 		if item == itemToMove {
+			if !character.addItem(item) {
+				level.addEvent(Event{Kind: InventoryFull, Actor: character.Name, Target: item.Name, Pos: pos})
+				return
+			}
+			level.addEvent(Event{Kind: PickUpItems, Actor: character.Name, Target: item.Name, Pos: pos})
 			items = append(items[:i], items[i+1:]...)
 			level.Items[pos] = items
-			character.Items = append(character.Items, item)
+			level.onItemPickup(character, item)
 			return
 		}
 	}
@@ -63,135 +102,241 @@ func Equip(itemToEquip *Items, character *Character) {
 				character.Sword = itemToEquip
 			} else if item.Type == Armour {
 				character.Armour = itemToEquip
+			} else if item.Type == Launcher {
+				character.Ranged = itemToEquip
 			}
 			return
 		}
 	}
 }
 
-func isClosedDoor(level *Level, x, y int) bool {
-	if x < 0 || x >= int(len(level.Map[0])) || y < 0 || y >= int(len(level.Map)) {
-		return false
+func (level *Level) UseItem(itemToUse *Items, character *Character) {
+	for i, item := range character.Items {
+		if item == itemToUse && item.Type == Potion {
+			item.Count--
+			if item.Count <= 0 {
+				character.Items = append(character.Items[:i], character.Items[i+1:]...)
+			}
+			healed := int(item.Power)
+			if character.Hp+healed > character.MaxHp {
+				healed = character.MaxHp - character.Hp
+			}
+			character.Hp += healed
+			level.addEvent(Event{Kind: DrinkPotion, Actor: character.Name, Target: item.Name, Amount: healed, Pos: character.Pos})
+			return
+		}
+		if item == itemToUse && item.Type == Scroll {
+			if level.readScroll(item, character) {
+				item.Count--
+				if item.Count <= 0 {
+					character.Items = append(character.Items[:i], character.Items[i+1:]...)
+				}
+			}
+			return
+		}
 	}
-	return level.Map[y][x].OverlayRune == ClosedDoor
 }
 
-func canWalk(level *Level, x, y int) bool {
-	if x < 0 || x >= int(len(level.Map[0])) || y < 0 || y >= int(len(level.Map)) {
+func isClosedDoor(level *Level, x, y int) bool {
+	if !level.inBounds(x, y) {
 		return false
 	}
-	switch level.Map[y][x].Rune {
-	case StoneWall, HellWall, Blank:
+	def := TileDefinition(level.Map[y][x].OverlayRune)
+	return def.OpensTo != Blank && !def.Locked
+}
+
+func isLockedDoor(level *Level, x, y int) bool {
+	if !level.inBounds(x, y) {
 		return false
 	}
-	switch level.Map[y][x].OverlayRune {
-	case ClosedDoor:
+	return TileDefinition(level.Map[y][x].OverlayRune).Locked
+}
+
+func canWalk(level *Level, x, y int) bool {
+	if !level.inBounds(x, y) || !level.Map[y][x].walkable() {
 		return false
 	}
 	_, exist := level.Monsters[Pos{x, y}]
 	return !exist
 }
 
+func movementCost(level *Level, pos Pos) int {
+	return TileDefinition(level.Map[pos.Y][pos.X].Rune).Cost
+}
+
+// canCutCorner stops diagonal steps from squeezing between two walls or
+// around the corner of a wall or door.
+func canCutCorner(level *Level, from, to Pos) bool {
+	return canSee(level, to.X, from.Y) && canSee(level, from.X, to.Y)
+}
+
 func canSee(level *Level, x, y int) bool {
-	if x < 0 || x >= int(len(level.Map[0])) || y < 0 || y >= int(len(level.Map)) {
-		return false
-	}
-	switch level.Map[y][x].Rune {
-	case StoneWall, HellWall, Blank:
-		return false
-	}
-	switch level.Map[y][x].OverlayRune {
-	case ClosedDoor:
-		return false
+	return level.inBounds(x, y) && level.Map[y][x].transparent()
+}
+
+// partyCoins counts the coins of every player, since the whole party takes
+// the stairs together and pays for them together.
+func (game *Game) partyCoins() int {
+	coins := 0
+	for _, p := range game.Players {
+		coins += p.Coins
 	}
-	return true
+	return coins
 }
 
-func (game *Game) move(to Pos) {
+func (game *Game) move(player *Player, to Pos) {
 	level := game.CurrentLevel
-	player := level.Player
 	levelandPos := level.Portals[to]
-	level.LastEvent = Move
 	if levelandPos != nil {
-		if level.Coins >= 5 {
-			level.LastEvent = Portal
+		if game.partyCoins() >= 5 {
 			game.CurrentLevel = levelandPos.Level
-			game.CurrentLevel.Player.Pos = levelandPos.Pos
-			level.lineOfSight()
+			for _, p := range game.Players {
+				p.Coins = 0
+			}
+			game.CurrentLevel.TurnEvents = nil
+			game.CurrentLevel.placeParty(game.Players, levelandPos.Pos)
+			game.CurrentLevel.addEvent(Event{Kind: Portal, Actor: player.Name, Pos: levelandPos.Pos})
+			for _, hook := range game.hooks {
+				hook.OnLevelChange(game, level, game.CurrentLevel)
+			}
 		} else {
-			level.LastEvent = FailedPortal
+			level.addEvent(Event{Kind: FailedPortal, Actor: player.Name, Pos: to})
 		}
 	} else {
+		from := player.Pos
 		player.Pos = to
-		for y := range level.Map {
-			for x := range level.Map[y] {
-				level.Map[y][x].Visible = false
-			}
+		level.invalidateDistanceMaps()
+		level.addEvent(Event{Kind: Move, Actor: player.Name, Pos: to})
+		level.onMove(&player.Character, from, to)
+		if cost := movementCost(level, to); cost > game.turnCost {
+			game.turnCost = cost
+		}
+		level.lineOfSight(player)
+		level.triggerTrap(&player.Character)
+		if player.Hp <= 0 {
+			level.killPlayer(player)
 		}
-		level.lineOfSight()
 	}
 }
 
-func (game *Game) resolveMovement(pos Pos) {
+func (game *Game) resolveMovement(player *Player, pos Pos) {
 	level := game.CurrentLevel
 	monster, exist := level.Monsters[pos]
 	if exist {
-		level.Attack(&level.Player.Character, &monster.Character)
-		level.LastEvent = Attacking
+		level.Attack(&player.Character, &monster.Character)
 		if monster.Character.Hp <= 0 {
 			monster.Dead(level)
-			level.LastEvent = MonsterDeath
+			player.gainExperience(monster.Experience, level)
 		}
-		if level.Player.Character.Hp <= 0 {
-			panic("You died")
+		if player.Character.Hp <= 0 {
+			level.killPlayer(player)
 		}
+	} else if level.playerAt(pos) != nil {
+		return
 	} else if canWalk(level, pos.X, pos.Y) {
-		game.move(pos) // todo
+		game.move(player, pos) // todo
 		if level.Map[pos.Y][pos.X].OverlayRune == Coin {
-			level.Coins++
+			player.Coins++
 			level.Map[pos.Y][pos.X].OverlayRune = Blank
+			level.addEvent(Event{Kind: CollectCoin, Actor: player.Name, Amount: player.Coins, Pos: pos})
 		}
 	} else if isClosedDoor(level, pos.X, pos.Y) {
-		level.Map[pos.Y][pos.X].OverlayRune = OpenedDoor
-		level.lineOfSight()
-		level.LastEvent = OpenDoor
+		level.Map[pos.Y][pos.X].OverlayRune = TileDefinition(level.Map[pos.Y][pos.X].OverlayRune).OpensTo
+		level.invalidateDistanceMaps()
+		level.updateSight()
+		level.addEvent(Event{Kind: OpenDoor, Actor: player.Name, Pos: pos})
+	} else if isLockedDoor(level, pos.X, pos.Y) {
+		level.unlockDoor(pos, &player.Character)
 	}
 }
 
-func (game *Game) handleInput(input *Input) {
+func (game *Game) handleInput(player *Player, input *Input) {
 	level := game.CurrentLevel
 	switch input.Input {
 	case Up:
-		to := Pos{level.Player.X, level.Player.Y - 1}
-		game.resolveMovement(to) //todo
+		to := Pos{player.X, player.Y - 1}
+		game.resolveMovement(player, to) //todo
 	case Left:
-		to := Pos{level.Player.X - 1, level.Player.Y}
-		game.resolveMovement(to)
+		to := Pos{player.X - 1, player.Y}
+		game.resolveMovement(player, to)
 	case Right:
-		to := Pos{level.Player.X + 1, level.Player.Y}
-		game.resolveMovement(to)
+		to := Pos{player.X + 1, player.Y}
+		game.resolveMovement(player, to)
 	case Down:
-		to := Pos{level.Player.X, level.Player.Y + 1}
-		game.resolveMovement(to)
+		to := Pos{player.X, player.Y + 1}
+		game.resolveMovement(player, to)
+	case UpLeft, UpRight, DownLeft, DownRight:
+		to := player.Pos
+		if input.Input == UpLeft || input.Input == DownLeft {
+			to.X--
+		} else {
+			to.X++
+		}
+		if input.Input == UpLeft || input.Input == UpRight {
+			to.Y--
+		} else {
+			to.Y++
+		}
+		if level.Diagonal && canCutCorner(level, player.Pos, to) {
+			game.resolveMovement(player, to)
+		}
+	case Search:
+		level.search(player)
 	case TakeAllItems:
-		for _, item := range level.Items[level.Player.Pos] {
-			level.MoveItem(item, &level.Player.Character)
+		items := append([]*Items(nil), level.Items[player.Pos]...)
+		for _, item := range items {
+			level.MoveItem(item, &player.Character)
 		}
 	case DropItem:
-		level.DropItem(input.Item, &level.Player.Character)
+		if item := level.findItem(player, input.Item); item != nil {
+			level.DropItem(item, &player.Character)
+		}
 	case TakeItem:
-		level.MoveItem(input.Item, &level.Player.Character)
+		if item := level.findItem(player, input.Item); item != nil {
+			level.MoveItem(item, &player.Character)
+		}
 	case EquipItem:
-		Equip(input.Item, &level.Player.Character)
-	case CloseWindow:
-		close(input.LevelChannel)
-		chanIndex := 0
-		for i, c := range game.LevelChan {
-			if c == input.LevelChannel {
-				chanIndex = i
-				break
+		if item := level.findItem(player, input.Item); item != nil {
+			Equip(item, &player.Character)
+			level.addEvent(Event{Kind: EquipItems, Actor: player.Name, Target: item.Name, Pos: player.Pos})
+		}
+	case UseItem:
+		if item := level.findItem(player, input.Item); item != nil {
+			level.UseItem(item, &player.Character)
+		}
+	case Fire:
+		level.playerFire(player, level.findItem(player, input.Item), input.Target)
+	case Cast:
+		level.playerCast(player, input.Slot, input.Target)
+	case AssignQuickSlot:
+		if input.Slot >= 0 && input.Slot < QuickSlotCount {
+			name := ""
+			if item := level.findItem(player, input.Item); item != nil {
+				name = item.Name
 			}
+			player.QuickSlots[input.Slot] = name
 		}
-		game.LevelChan = append(game.LevelChan[:chanIndex], game.LevelChan[chanIndex+1:]...)
 	}
-}
\ No newline at end of file
+}
+
+func (game *Game) closeWindow(levelChannel chan *Level) {
+	close(levelChannel)
+	chanIndex := 0
+	for i, c := range game.LevelChan {
+		if c == levelChannel {
+			chanIndex = i
+			break
+		}
+	}
+	game.LevelChan = append(game.LevelChan[:chanIndex], game.LevelChan[chanIndex+1:]...)
+	player := game.windows[levelChannel]
+	delete(game.windows, levelChannel)
+	// Nobody is left to play for the window's player, so the round goes on
+	// without them.
+	if player != nil {
+		player.away = true
+		if game.endRound() {
+			game.sendLevels()
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPartyPaysForStairsTogether(t *testing.T) {
+	game := newGame(2, rand.New(rand.NewSource(1)))
+	first, second := game.Players[0], game.Players[1]
+	start := game.CurrentLevel
+	var stairs Pos
+	for pos := range start.Portals {
+		stairs = pos
+	}
+
+	first.Coins, second.Coins = 3, 1
+	game.move(first, stairs)
+	if game.CurrentLevel != start || !start.HasEvent(FailedPortal) {
+		t.Fatal("party took the stairs with 4 coins")
+	}
+
+	second.Coins = 2
+	game.move(first, stairs)
+	if game.CurrentLevel == start {
+		t.Fatal("party with 5 coins between them could not take the stairs")
+	}
+	if first.Coins != 0 || second.Coins != 0 {
+		t.Errorf("players kept %d and %d coins after paying", first.Coins, second.Coins)
+	}
+}
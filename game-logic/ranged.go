@@ -0,0 +1,165 @@
+package game
+
+// projectilePath is the way a projectile flies from from towards to, at
+// most maxRange tiles. It stops before anything that blocks sight and on
+// the first creature it reaches.
+func (level *Level) projectilePath(from, to Pos, maxRange int) []Pos {
+	path := make([]Pos, 0, maxRange)
+	bresenham(from, to, func(pos Pos) bool {
+		if pos == from {
+			return true
+		}
+		if len(path) >= maxRange || !level.inBounds(pos.X, pos.Y) || !canSee(level, pos.X, pos.Y) {
+			return false
+		}
+		path = append(path, pos)
+		return level.characterAt(pos) == nil
+	})
+	return path
+}
+
+func (level *Level) characterAt(pos Pos) *Character {
+	if monster, exist := level.Monsters[pos]; exist {
+		return &monster.Character
+	}
+	if player := level.playerAt(pos); player != nil {
+		return &player.Character
+	}
+	return nil
+}
+
+// rangedWeapon picks what c attacks with from afar and takes one projectile
+// for it: thrown itself when it is given, or else ammo for the ranged
+// weapon c has equipped. It returns nil when c has nothing to fire.
+func (c *Character) rangedWeapon(thrown *Items) (weapon, projectile *Items) {
+	if thrown != nil {
+		if thrown.Type != Thrown {
+			return nil, nil
+		}
+		return thrown, c.takeOne(thrown)
+	}
+	weapon = c.Ranged
+	if weapon == nil {
+		return nil, nil
+	}
+	if weapon.Ammo == "" {
+		return weapon, weapon
+	}
+	for _, item := range c.Items {
+		if item.Type == Ammo && item.Name == weapon.Ammo {
+			return weapon, c.takeOne(item)
+		}
+	}
+	return nil, nil
+}
+
+// takeOne takes a single item off a stack in c's inventory.
+func (c *Character) takeOne(stack *Items) *Items {
+	if stack.Count > 1 {
+		stack.Count--
+		one := *stack
+		one.Count = 1
+		one.ID = newItemID()
+		return &one
+	}
+	for i, item := range c.Items {
+		if item == stack {
+			c.Items = append(c.Items[:i], c.Items[i+1:]...)
+			break
+		}
+	}
+	return stack
+}
+
+// fire shoots projectile from weapon at target and returns the character it
+// hit, if any. Arrows, bolts and knives end up on the floor where they
+// stop; spit leaves a web.
+func (level *Level) fire(shooter *Character, weapon, projectile *Items, target Pos) *Character {
+	shooter.Ap--
+	path := level.projectilePath(shooter.Pos, target, weapon.Range)
+	land := shooter.Pos
+	var hit *Character
+	if len(path) > 0 {
+		land = path[len(path)-1]
+		hit = level.characterAt(land)
+	}
+	level.addEvent(Event{Kind: Fired, Actor: shooter.Name, Target: projectile.Name, Pos: land})
+	if hit != nil {
+		level.damage(shooter, hit, int(float32(shooter.Strength)*weapon.Power))
+	}
+	if projectile.Type == Spit {
+		level.spinWeb(land, hit)
+	} else {
+		projectile.Pos = land
+		level.Items[land] = append(level.Items[land], projectile)
+	}
+	return hit
+}
+
+func (level *Level) spinWeb(pos Pos, caught *Character) {
+	tile := &level.Map[pos.Y][pos.X]
+	if TileDefinition(tile.Rune).Floor && tile.OverlayRune == Blank {
+		tile.Rune = Web
+		level.invalidateDistanceMaps()
+	}
+	if caught != nil {
+		level.addEvent(Event{Kind: Webbed, Target: caught.Name, Pos: pos})
+	}
+}
+
+// resolveHit deals with hit dying from a ranged attack and returns the
+// monster that died, if it was one.
+func (level *Level) resolveHit(hit *Character) *Monster {
+	if hit == nil || hit.Hp > 0 {
+		return nil
+	}
+	if monster := level.Monsters[hit.Pos]; monster != nil && &monster.Character == hit {
+		monster.Dead(level)
+		return monster
+	}
+	if player := level.playerOf(hit); player != nil {
+		level.killPlayer(player)
+	}
+	return nil
+}
+
+func (level *Level) playerFire(player *Player, thrown *Items, target Pos) {
+	if target == player.Pos || !level.inBounds(target.X, target.Y) {
+		return
+	}
+	weapon, projectile := player.rangedWeapon(thrown)
+	if weapon == nil {
+		level.addEvent(Event{Kind: NothingToFire, Actor: player.Name, Pos: player.Pos})
+		return
+	}
+	hit := level.fire(&player.Character, weapon, projectile, target)
+	if monster := level.resolveHit(hit); monster != nil {
+		player.gainExperience(monster.Experience, level)
+	}
+}
+
+// shoot has the monster attack a player it has a clear shot at but is not
+// next to. Spitters leave players already caught in a web alone. It
+// reports whether the monster spent its turn.
+func (m *Monster) shoot(level *Level) bool {
+	for _, player := range level.alivePlayers() {
+		dx, dy := player.X-m.X, player.Y-m.Y
+		if dx >= -1 && dx <= 1 && dy >= -1 && dy <= 1 {
+			continue
+		}
+		if m.Ranged.Type == Spit && level.Map[player.Y][player.X].Rune == Web {
+			continue
+		}
+		path := level.projectilePath(m.Pos, player.Pos, m.Ranged.Range)
+		if len(path) == 0 || path[len(path)-1] != player.Pos {
+			continue
+		}
+		weapon, projectile := m.rangedWeapon(nil)
+		if weapon == nil {
+			return false
+		}
+		level.resolveHit(level.fire(&m.Character, weapon, projectile, player.Pos))
+		return true
+	}
+	return false
+}
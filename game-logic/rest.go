@@ -0,0 +1,56 @@
+package game
+
+// regenPoints is how much regeneration a character builds up for each hit
+// point they heal. Every round adds their max HP and ten per point of
+// strength, so the starting player heals one hit point a round.
+const regenPoints = 200
+
+// maxRestTurns is the longest a player rests in one go.
+const maxRestTurns = 300
+
+// regenerate heals every living character a little at the end of a round.
+// Poison stops healing.
+func (level *Level) regenerate() {
+	for _, player := range level.alivePlayers() {
+		player.regenerate()
+	}
+	for _, monster := range level.Monsters {
+		monster.regenerate()
+	}
+}
+
+func (c *Character) regenerate() {
+	if c.Poison > 0 || c.Hp >= c.MaxHp {
+		c.regen = 0
+		return
+	}
+	c.regen += c.MaxHp + c.Strength*10
+	c.Hp += c.regen / regenPoints
+	c.regen %= regenPoints
+	if c.Hp > c.MaxHp {
+		c.Hp = c.MaxHp
+	}
+}
+
+// restAction lets the player pass another turn resting, unless they are
+// fully healed or it is not safe to.
+func (level *Level) restAction(player *Player) (*Input, bool) {
+	if player.Hp >= player.MaxHp {
+		level.addEvent(Event{Kind: Rested, Actor: player.Name, Pos: player.Pos})
+		return nil, false
+	}
+	if monster := level.monsterInView(player); monster != nil {
+		level.interruptAuto(player, monster.Name+" is in view")
+		return nil, false
+	}
+	if player.Poison > 0 {
+		level.interruptAuto(player, "they are poisoned")
+		return nil, false
+	}
+	if player.rested >= maxRestTurns {
+		level.interruptAuto(player, "they cannot rest any longer")
+		return nil, false
+	}
+	player.rested++
+	return &Input{Input: Rest, Player: player.ID}, true
+}
@@ -0,0 +1,110 @@
+package game
+
+func copyItem(item *Items) *Items {
+	if item == nil {
+		return nil
+	}
+	c := *item
+	return &c
+}
+
+func copyItems(items []*Items) []*Items {
+	if items == nil {
+		return nil
+	}
+	res := make([]*Items, len(items))
+	for i, item := range items {
+		res[i] = copyItem(item)
+	}
+	return res
+}
+
+func copyCharacter(c Character) Character {
+	c.Items = copyItems(c.Items)
+	c.Sword = copyItem(c.Sword)
+	c.Armour = copyItem(c.Armour)
+	c.Helmet = copyItem(c.Helmet)
+	c.Ranged = copyItem(c.Ranged)
+	c.Abilities = append([]Ability(nil), c.Abilities...)
+	return c
+}
+
+func copyPlayer(player *Player) *Player {
+	p := *player
+	p.Character = copyCharacter(player.Character)
+	// The monsters in view are live ones, and only auto actions need them.
+	p.inView = nil
+	return &p
+}
+
+// Snapshot returns a deep copy of the level that front-ends can read while
+// the game keeps running, as seen by viewer: its Player is the viewer and
+// the tiles' Visible and Seen follow the viewer's field of view. Portals
+// in the copy keep their position but not the destination level, and
+// game-only state such as distance maps is left out.
+func (level *Level) Snapshot(viewer *Player) *Level {
+	s := &Level{
+		Diagonal: level.Diagonal,
+	}
+	if viewer == nil {
+		viewer = level.Players[0]
+	}
+
+	view := level.view(viewer)
+	s.Map = make([][]Tile, len(level.Map))
+	for y := range level.Map {
+		s.Map[y] = append([]Tile(nil), level.Map[y]...)
+		for x := range s.Map[y] {
+			s.Map[y][x].Visible = view.visible[y][x]
+			s.Map[y][x].Seen = view.seen[y][x]
+		}
+	}
+
+	s.Players = make([]*Player, len(level.Players))
+	for i, player := range level.Players {
+		s.Players[i] = copyPlayer(player)
+		if player == viewer {
+			s.Player = s.Players[i]
+		}
+	}
+
+	s.Monsters = make(map[Pos]*Monster, len(level.Monsters))
+	for pos, monster := range level.Monsters {
+		m := *monster
+		m.Character = copyCharacter(monster.Character)
+		s.Monsters[pos] = &m
+	}
+
+	s.Portals = make(map[Pos]*LevelPos, len(level.Portals))
+	for pos, portal := range level.Portals {
+		s.Portals[pos] = &LevelPos{nil, portal.Pos}
+	}
+
+	s.Items = make(map[Pos][]*Items, len(level.Items))
+	for pos, items := range level.Items {
+		s.Items[pos] = copyItems(items)
+	}
+
+	s.Locks = make(map[Pos]string, len(level.Locks))
+	for pos, id := range level.Locks {
+		s.Locks[pos] = id
+	}
+
+	s.Traps = make(map[Pos]*Trap, len(level.Traps))
+	for pos, trap := range level.Traps {
+		t := *trap
+		s.Traps[pos] = &t
+	}
+
+	s.Debug = make(map[Pos]bool, len(level.Debug))
+	for pos, debug := range level.Debug {
+		s.Debug[pos] = debug
+	}
+
+	// Logged events are never changed once appended, so the copy can share
+	// them as long as its slice cannot grow into the live one.
+	s.Log = &MessageLog{level.Log.Turn, level.Log.Entries[:len(level.Log.Entries):len(level.Log.Entries)]}
+	s.TurnEvents = append([]Event(nil), level.TurnEvents...)
+
+	return s
+}
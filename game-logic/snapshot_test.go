@@ -0,0 +1,18 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSnapshotLeavesOutMonstersInView(t *testing.T) {
+	game := newGame(1, rand.New(rand.NewSource(1)))
+	player := game.Players[0]
+	game.startAuto(player, &Input{Input: Explore})
+	if player.inView == nil {
+		t.Fatal("exploring did not note the monsters in view")
+	}
+	if s := game.CurrentLevel.Snapshot(player); s.Player.inView != nil {
+		t.Error("snapshot shares the monsters in view with the game")
+	}
+}
@@ -0,0 +1,106 @@
+package game
+
+import "sync"
+
+// Spectator watches a game without playing in it. Its LevelChan only
+// ever holds the latest level, so a slow spectator never holds up Run.
+type Spectator struct {
+	LevelChan chan *Level
+	fullView  bool
+}
+
+type spectatorList struct {
+	sync.Mutex
+	spectators []*Spectator
+	latest     [2]*Level
+}
+
+func latestIndex(fullView bool) int {
+	if fullView {
+		return 1
+	}
+	return 0
+}
+
+// AddSpectator can be called at any time, from any goroutine. A spectator
+// with fullView sees the whole level, otherwise it sees what the party
+// sees.
+func (game *Game) AddSpectator(fullView bool) *Spectator {
+	spectator := &Spectator{make(chan *Level, 1), fullView}
+	game.watchers.Lock()
+	defer game.watchers.Unlock()
+	game.watchers.spectators = append(game.watchers.spectators, spectator)
+	if latest := game.watchers.latest[latestIndex(fullView)]; latest != nil {
+		spectator.LevelChan <- latest
+	}
+	return spectator
+}
+
+// RemoveSpectator stops sending levels to the spectator and closes its
+// LevelChan.
+func (game *Game) RemoveSpectator(spectator *Spectator) {
+	game.watchers.Lock()
+	defer game.watchers.Unlock()
+	for i, s := range game.watchers.spectators {
+		if s == spectator {
+			game.watchers.spectators = append(game.watchers.spectators[:i], game.watchers.spectators[i+1:]...)
+			close(spectator.LevelChan)
+			return
+		}
+	}
+}
+
+func (game *Game) spectatorFor(levelChannel chan *Level) *Spectator {
+	game.watchers.Lock()
+	defer game.watchers.Unlock()
+	for _, s := range game.watchers.spectators {
+		if s.LevelChan == levelChannel {
+			return s
+		}
+	}
+	return nil
+}
+
+// sendSpectators takes no snapshots while nobody watches. A spectator
+// who comes in then gets its first level at the end of the next turn.
+func (game *Game) sendSpectators() {
+	game.watchers.Lock()
+	defer game.watchers.Unlock()
+	if len(game.watchers.spectators) == 0 {
+		game.watchers.latest = [2]*Level{}
+		return
+	}
+	party := game.CurrentLevel.spectatorSnapshot(false)
+	full := game.CurrentLevel.spectatorSnapshot(true)
+	game.watchers.latest = [2]*Level{party, full}
+	for _, s := range game.watchers.spectators {
+		select {
+		case <-s.LevelChan:
+		default:
+		}
+		s.LevelChan <- game.watchers.latest[latestIndex(s.fullView)]
+	}
+}
+
+// spectatorSnapshot is a snapshot that sees the whole level, or everything
+// any player sees.
+func (level *Level) spectatorSnapshot(fullView bool) *Level {
+	s := level.Snapshot(level.Players[0])
+	s.Spectator = true
+	for y := range s.Map {
+		for x := range s.Map[y] {
+			if fullView {
+				s.Map[y][x].Visible = true
+				s.Map[y][x].Seen = true
+				continue
+			}
+			for _, player := range level.alivePlayers() {
+				s.Map[y][x].Visible = s.Map[y][x].Visible || level.view(player).visible[y][x]
+			}
+			for _, view := range level.views {
+				s.Map[y][x].Seen = s.Map[y][x].Seen || view.seen[y][x]
+			}
+		}
+	}
+	return s
+}
@@ -0,0 +1,25 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSendSpectators(t *testing.T) {
+	game := newGame(0, rand.New(rand.NewSource(1)))
+	game.sendSpectators()
+	if game.watchers.latest != [2]*Level{} {
+		t.Error("snapshots were taken with nobody watching")
+	}
+
+	spectator := game.AddSpectator(true)
+	game.sendSpectators()
+	select {
+	case level := <-spectator.LevelChan:
+		if !level.Spectator || !level.Map[0][0].Seen {
+			t.Error("spectator was not sent the whole level")
+		}
+	default:
+		t.Error("no level was sent to the spectator")
+	}
+}
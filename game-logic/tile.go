@@ -0,0 +1,146 @@
+package game
+
+import "math/rand"
+
+// TileDef describes how a map character behaves. Tiles, and the monsters,
+// items, traps and start that Spawn puts on the floor, are all looked up
+// here, so a new one only needs a new entry. Sound names the sound made on
+// the tile, which front-ends map to their own assets. Theme names the kind
+// of level a tile belongs to, "any" for those that fit every level.
+type TileDef struct {
+	Rune        rune
+	Name        string
+	Walkable    bool
+	Transparent bool
+	Cost        int
+	Sprite      rune
+	Sound       string
+	Theme       string
+	Overlay     bool
+	Floor       bool
+	OpensTo     rune
+	Locked      bool
+	Spawn       func(level *Level, def *TileDef, pos Pos, rng *rand.Rand)
+}
+
+var tileDefs = map[rune]*TileDef{}
+
+// asciiTileDefs mirrors tileDefs for the runes most maps use, since tiles
+// are looked up on every step of every search.
+var asciiTileDefs [128]*TileDef
+
+func registerTile(def *TileDef) {
+	if def.Cost == 0 {
+		def.Cost = 1
+	}
+	if def.Sprite == 0 {
+		def.Sprite = def.Rune
+	}
+	tileDefs[def.Rune] = def
+	if def.Rune >= 0 && int(def.Rune) < len(asciiTileDefs) {
+		asciiTileDefs[def.Rune] = def
+	}
+}
+
+func init() {
+	registerTile(&TileDef{Rune: Blank, Name: "Nothing", Sprite: Blank, Theme: "any"})
+	registerTile(&TileDef{Rune: Pending, Name: "Pending", Walkable: true, Transparent: true, Theme: "any"})
+
+	registerTile(&TileDef{Rune: StoneWall, Name: "Stone wall", Theme: "dungeon"})
+	registerTile(&TileDef{Rune: HellWall, Name: "Hell wall", Theme: "hell"})
+	registerTile(&TileDef{Rune: DirtFloor, Name: "Dirt floor", Walkable: true, Transparent: true, Sound: "dirt", Floor: true, Theme: "dungeon"})
+	registerTile(&TileDef{Rune: HellFloor, Name: "Hell floor", Walkable: true, Transparent: true, Sound: "dirt", Floor: true, Theme: "hell"})
+	registerTile(&TileDef{Rune: Mud, Name: "Mud", Walkable: true, Transparent: true, Cost: 2, Sound: "dirt", Theme: "dungeon"})
+	registerTile(&TileDef{Rune: Water, Name: "Water", Walkable: true, Transparent: true, Cost: 3, Sound: "water", Theme: "dungeon"})
+	registerTile(&TileDef{Rune: Web, Name: "Web", Walkable: true, Transparent: true, Cost: 4, Theme: "dungeon"})
+	registerTile(&TileDef{Rune: Lava, Name: "Lava", Walkable: true, Transparent: true, Cost: 5, Theme: "hell"})
+	registerTile(&TileDef{Rune: Ice, Name: "Ice", Walkable: true, Transparent: true, Sound: "ice", Theme: "dungeon"})
+	registerTile(&TileDef{Rune: Bridge, Name: "Bridge", Walkable: true, Transparent: true, Sound: "wood", Theme: "dungeon"})
+
+	registerTile(&TileDef{Rune: ClosedDoor, Name: "Closed door", Overlay: true, OpensTo: OpenedDoor, Sound: "door", Theme: "any"})
+	registerTile(&TileDef{Rune: LockedDoor, Name: "Locked door", Overlay: true, OpensTo: OpenedDoor, Locked: true, Sound: "door", Theme: "any"})
+	registerTile(&TileDef{Rune: OpenedDoor, Name: "Opened door", Walkable: true, Transparent: true, Overlay: true, Sound: "door", Theme: "any"})
+	registerTile(&TileDef{Rune: Upstair, Name: "Up stairs", Walkable: true, Transparent: true, Overlay: true, Theme: "any"})
+	registerTile(&TileDef{Rune: Downstair, Name: "Down stairs", Walkable: true, Transparent: true, Overlay: true, Theme: "any"})
+	registerTile(&TileDef{Rune: Coin, Name: "Coin", Walkable: true, Transparent: true, Overlay: true, Theme: "any"})
+
+	registerTile(&TileDef{Rune: '@', Name: "Start", Spawn: spawnStart, Theme: "any"})
+
+	registerTile(&TileDef{Rune: 'R', Name: "Rat", Spawn: spawnMonster(NewRat), Theme: "any"})
+	registerTile(&TileDef{Rune: 'S', Name: "Spider", Spawn: spawnMonster(NewSpider), Theme: "any"})
+	registerTile(&TileDef{Rune: 'G', Name: "Ghost", Spawn: spawnMonster(NewGhost), Theme: "any"})
+
+	registerTile(&TileDef{Rune: 's', Name: "Sword", Spawn: spawnItem(NewSword), Theme: "any"})
+	registerTile(&TileDef{Rune: 'h', Name: "Helmet", Spawn: spawnItem(newHelmet), Theme: "any"})
+	registerTile(&TileDef{Rune: 'a', Name: "Armour", Spawn: spawnItem(newArmour), Theme: "any"})
+	registerTile(&TileDef{Rune: 'p', Name: "Potion", Spawn: spawnItem(newPotion), Theme: "any"})
+	registerTile(&TileDef{Rune: 'k', Name: "Key", Spawn: spawnItem(func(p Pos) *Items { return newKey(p, "") }), Theme: "any"})
+	registerTile(&TileDef{Rune: 'b', Name: "Bow", Spawn: spawnItem(newBow), Theme: "any"})
+	registerTile(&TileDef{Rune: 'c', Name: "Crossbow", Spawn: spawnItem(newCrossbow), Theme: "any"})
+	registerTile(&TileDef{Rune: 'r', Name: "Arrows", Spawn: spawnItem(func(p Pos) *Items { return newArrows(p, 10) }), Theme: "any"})
+	registerTile(&TileDef{Rune: 'o', Name: "Bolts", Spawn: spawnItem(func(p Pos) *Items { return newBolts(p, 10) }), Theme: "any"})
+	registerTile(&TileDef{Rune: 't', Name: "Throwing knives", Spawn: spawnItem(func(p Pos) *Items { return newThrowingKnives(p, 5) }), Theme: "any"})
+	registerTile(&TileDef{Rune: '?', Name: "Scroll", Spawn: spawnScroll, Theme: "any"})
+
+	registerTile(&TileDef{Rune: '^', Name: "Spike trap", Spawn: spawnTrap(SpikeTrap), Theme: "any"})
+	registerTile(&TileDef{Rune: '*', Name: "Teleport trap", Spawn: spawnTrap(TeleportTrap), Theme: "any"})
+	registerTile(&TileDef{Rune: '!', Name: "Alarm trap", Spawn: spawnTrap(AlarmTrap), Theme: "any"})
+	registerTile(&TileDef{Rune: '%', Name: "Poison gas trap", Spawn: spawnTrap(GasTrap), Theme: "any"})
+}
+
+func spawnStart(level *Level, def *TileDef, pos Pos, rng *rand.Rand) {
+	if level.start.X == 0 && level.start.Y == 0 {
+		level.start = pos
+	}
+}
+
+func spawnMonster(newMonster func(Pos, *rand.Rand) *Monster) func(*Level, *TileDef, Pos, *rand.Rand) {
+	return func(level *Level, def *TileDef, pos Pos, rng *rand.Rand) {
+		level.Monsters[pos] = newMonster(pos, rng)
+	}
+}
+
+func spawnItem(newItem func(Pos) *Items) func(*Level, *TileDef, Pos, *rand.Rand) {
+	return func(level *Level, def *TileDef, pos Pos, rng *rand.Rand) {
+		level.Items[pos] = append(level.Items[pos], newItem(pos))
+	}
+}
+
+func spawnScroll(level *Level, def *TileDef, pos Pos, rng *rand.Rand) {
+	level.Items[pos] = append(level.Items[pos], newScroll(pos, abilityNames[rng.Intn(len(abilityNames))]))
+}
+
+func spawnTrap(kind trapType) func(*Level, *TileDef, Pos, *rand.Rand) {
+	return func(level *Level, def *TileDef, pos Pos, rng *rand.Rand) {
+		level.Traps[pos] = &Trap{kind, Entity{pos, def.Rune, def.Name}, false}
+	}
+}
+
+func TileDefinition(r rune) *TileDef {
+	if r >= 0 && int(r) < len(asciiTileDefs) && asciiTileDefs[r] != nil {
+		return asciiTileDefs[r]
+	}
+	def, exist := tileDefs[r]
+	if !exist {
+		panic("unknown tile " + string(r))
+	}
+	return def
+}
+
+func (t Tile) walkable() bool {
+	if !TileDefinition(t.Rune).Walkable {
+		return false
+	}
+	return t.OverlayRune == Blank || TileDefinition(t.OverlayRune).Walkable
+}
+
+func (t Tile) transparent() bool {
+	if !TileDefinition(t.Rune).Transparent {
+		return false
+	}
+	return t.OverlayRune == Blank || TileDefinition(t.OverlayRune).Transparent
+}
+
+func (level *Level) inBounds(x, y int) bool {
+	return x >= 0 && x < len(level.Map[0]) && y >= 0 && y < len(level.Map)
+}
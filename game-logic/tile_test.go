@@ -0,0 +1,11 @@
+package game
+
+import "testing"
+
+func TestEveryTileHasTheme(t *testing.T) {
+	for r, def := range tileDefs {
+		if def.Theme == "" {
+			t.Errorf("%s (%q) has no theme", def.Name, r)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package game
+
+type trapType int
+
+const (
+	SpikeTrap trapType = iota
+	TeleportTrap
+	AlarmTrap
+	GasTrap
+)
+
+type Trap struct {
+	Type trapType
+	Entity
+	Hidden bool
+}
+
+const (
+	spikeDamage  = 10
+	poisonTurns  = 5
+	poisonDamage = 2
+	searchRange  = 2
+)
+
+func (level *Level) isKnownTrap(pos Pos) bool {
+	trap, exist := level.Traps[pos]
+	return exist && !trap.Hidden
+}
+
+func (level *Level) triggerTrap(character *Character) {
+	trap, exist := level.Traps[character.Pos]
+	if !exist {
+		return
+	}
+	trap.Hidden = false
+	level.invalidateDistanceMaps()
+	level.addEvent(Event{Kind: TrapTriggered, Actor: character.Name, Target: trap.Name, Pos: trap.Pos})
+
+	switch trap.Type {
+	case SpikeTrap:
+		level.hurt(character, spikeDamage)
+	case TeleportTrap:
+		level.teleport(character)
+	case AlarmTrap:
+		for _, monster := range level.Monsters {
+			monster.Ap++
+		}
+		level.addEvent(Event{Kind: AlarmRaised, Actor: character.Name, Pos: trap.Pos})
+	case GasTrap:
+		character.Poison = poisonTurns
+	}
+}
+
+func (level *Level) teleport(character *Character) {
+	for tries := 0; tries < 1000; tries++ {
+		y := level.game.rng.Intn(len(level.Map))
+		x := level.game.rng.Intn(len(level.Map[y]))
+		to := Pos{x, y}
+		if !canWalk(level, x, y) || level.playerAt(to) != nil || level.Traps[to] != nil {
+			continue
+		}
+		level.teleportTo(character, to)
+		return
+	}
+}
+
+func (level *Level) teleportTo(character *Character, to Pos) {
+	from := character.Pos
+	if player := level.playerOf(character); player != nil {
+		player.Pos = to
+		level.invalidateDistanceMaps()
+		level.lineOfSight(player)
+	} else {
+		monster := level.Monsters[character.Pos]
+		delete(level.Monsters, character.Pos)
+		monster.Pos = to
+		level.Monsters[to] = monster
+	}
+	level.onMove(character, from, to)
+}
+
+func (level *Level) tickPoison() {
+	for _, player := range level.alivePlayers() {
+		if player.Poison > 0 {
+			player.Poison--
+			level.hurt(&player.Character, poisonDamage)
+		}
+	}
+	for _, monster := range level.monsterOrder() {
+		if monster.Poison > 0 {
+			monster.Poison--
+			monster.Hp -= poisonDamage
+			if monster.Hp <= 0 {
+				level.addEvent(Event{Kind: MonsterDeath, Target: monster.Name, Pos: monster.Pos})
+				monster.Dead(level)
+			}
+		}
+	}
+	for _, player := range level.alivePlayers() {
+		if player.Hp <= 0 {
+			level.killPlayer(player)
+		}
+	}
+}
+
+func (level *Level) search(player *Player) {
+	pos := player.Pos
+	found := false
+	for y := pos.Y - searchRange; y <= pos.Y+searchRange; y++ {
+		for x := pos.X - searchRange; x <= pos.X+searchRange; x++ {
+			trap, exist := level.Traps[Pos{x, y}]
+			if exist && trap.Hidden {
+				trap.Hidden = false
+				level.invalidateDistanceMaps()
+				found = true
+				level.addEvent(Event{Kind: TrapFound, Actor: player.Name, Target: trap.Name, Pos: trap.Pos})
+			}
+		}
+	}
+	if !found {
+		level.addEvent(Event{Kind: Searched, Actor: player.Name, Pos: pos})
+	}
+}
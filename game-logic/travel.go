@@ -0,0 +1,68 @@
+package game
+
+func (level *Level) isStairs(pos Pos) bool {
+	overlay := level.Map[pos.Y][pos.X].OverlayRune
+	return level.Portals[pos] != nil || overlay == Upstair || overlay == Downstair
+}
+
+func (level *Level) isCoin(pos Pos) bool {
+	return level.Map[pos.Y][pos.X].OverlayRune == Coin
+}
+
+// nearestSeen finds the closest tile the player has seen that matches,
+// walking only over tiles they have seen.
+func (level *Level) nearestSeen(player *Player, match func(Pos) bool) (Pos, bool) {
+	view := level.view(player)
+	queue := []Pos{player.Pos}
+	visited := map[Pos]bool{player.Pos: true}
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+		if curr != player.Pos && match(curr) {
+			return curr, true
+		}
+		if curr != player.Pos && level.Portals[curr] != nil {
+			continue
+		}
+		for _, next := range level.adjacent(curr, false, make([]Pos, 0, 8)) {
+			if !visited[next] && view.seen[next.Y][next.X] && !level.isKnownTrap(next) {
+				visited[next] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+	return Pos{}, false
+}
+
+// travelAction decides the player's next step towards travelTo. When
+// there is none it tells the player why and returns false.
+func (level *Level) travelAction(player *Player) (*Input, bool) {
+	goal := player.travelTo
+	if goal == player.Pos {
+		return nil, false
+	}
+	if !level.inBounds(goal.X, goal.Y) {
+		level.interruptAuto(player, "they don't know where to go")
+		return nil, false
+	}
+	if !level.view(player).seen[goal.Y][goal.X] || !level.Map[goal.Y][goal.X].walkable() {
+		level.interruptAuto(player, "they don't know the way there")
+		return nil, false
+	}
+	if monster := level.monsterCameIntoView(player); monster != nil {
+		level.interruptAuto(player, monster.Name+" came into view")
+		return nil, false
+	}
+
+	path := level.aStar(player.Pos, goal)
+	if len(path) < 2 {
+		level.interruptAuto(player, "the way is blocked")
+		return nil, false
+	}
+	next := path[1]
+	if level.playerAt(next) != nil || (level.Portals[next] != nil && next != goal) {
+		level.interruptAuto(player, "the way is blocked")
+		return nil, false
+	}
+	return &Input{Input: directionTo(player.Pos, next), Player: player.ID}, true
+}
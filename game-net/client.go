@@ -0,0 +1,88 @@
+package gamenet
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+
+	"github.com/ahmadfarhanstwn/rpg/game-logic"
+)
+
+// Client plays on a Server through the same channels a local Game offers,
+// so any front-end can run on top of it.
+type Client struct {
+	LevelChan chan *game.Level
+	InputChan chan *game.Input
+	conn      net.Conn
+	received  chan struct{}
+	stopped   chan struct{}
+}
+
+func Dial(addr string) (*Client, error) {
+	return dial(addr, helloMessage{})
+}
+
+// DialSpectator joins only to watch, seeing the whole level with fullView
+// and what the players see otherwise.
+func DialSpectator(addr string, fullView bool) (*Client, error) {
+	return dial(addr, helloMessage{true, fullView})
+}
+
+func dial(addr string, hello helloMessage) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(conn).Encode(hello); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	client := &Client{make(chan *game.Level), make(chan *game.Input), conn, make(chan struct{}), make(chan struct{})}
+	go client.receive()
+	return client, nil
+}
+
+func (client *Client) receive() {
+	defer close(client.LevelChan)
+	defer close(client.received)
+	log := &game.MessageLog{}
+	scanner := bufio.NewScanner(client.conn)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var msg levelMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return
+		}
+		select {
+		case client.LevelChan <- msg.level(log):
+		case <-client.stopped:
+			return
+		}
+	}
+}
+
+// Run sends inputs to the server until the front-end quits or closes its
+// window, or the server goes away.
+func (client *Client) Run() {
+	defer client.conn.Close()
+	defer close(client.stopped)
+	encoder := json.NewEncoder(client.conn)
+	for {
+		select {
+		case input := <-client.InputChan:
+			// The server has the player join and leave with the
+			// connection.
+			if input.Input == game.Join || input.Input == game.Leave {
+				continue
+			}
+			if err := encoder.Encode(newInputMessage(input)); err != nil {
+				return
+			}
+			if input.Input == game.Quit || input.Input == game.CloseWindow {
+				return
+			}
+		case <-client.received:
+			return
+		}
+	}
+}
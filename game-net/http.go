@@ -0,0 +1,177 @@
+package gamenet
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ahmadfarhanstwn/rpg/game-logic"
+)
+
+// actionTimeout is how long POST /action waits for the turn to be played.
+// The game ignores actions from a player that already acted this round, so
+// there may be no new level to wait for. A player that has not posted an
+// action for that long leaves the game until its next action, so the
+// other players are not kept waiting.
+const actionTimeout = time.Second
+
+// HTTPHandler lets scripts play through a window of a Game, or a Client,
+// over HTTP:
+//
+//	GET  /level?since=N  the level as the player sees it, with the logged
+//	                     events from the Nth on
+//	POST /action         play an action such as {"input": 4} for Up, or
+//	                     {"input": 12, "item": 7} to use item 7, or
+//	                     {"input": 21, "target": {"X": 3, "Y": 4}} to
+//	                     travel to a tile (25 fires at it instead, and
+//	                     26 casts the ability in "slot" at it), and
+//	                     answer with the level once the turn was played,
+//	                     with the events logged since
+//
+// Inputs are numbered as game.InputState.
+// Monsters and items are only listed on visible tiles, traps only once
+// found, and tiles the player has never seen are Blank.
+type HTTPHandler struct {
+	levels  chan *game.Level
+	inputs  chan *game.Input
+	mu      sync.Mutex
+	latest  *game.Level
+	updated chan struct{}
+	mux     *http.ServeMux
+	// playing serializes what is sent to the game, so that joining,
+	// acting and leaving always arrive in order.
+	playing sync.Mutex
+	joined  bool
+	actions int
+}
+
+func NewHTTPHandler(levels chan *game.Level, inputs chan *game.Input) *HTTPHandler {
+	handler := &HTTPHandler{levels: levels, inputs: inputs, updated: make(chan struct{})}
+	handler.mux = http.NewServeMux()
+	handler.mux.HandleFunc("/level", handler.getLevel)
+	handler.mux.HandleFunc("/action", handler.postAction)
+	go handler.receive()
+	return handler
+}
+
+func (handler *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler.mux.ServeHTTP(w, r)
+}
+
+// receive has the player leave before the first level is published, so
+// the game never waits on a player no script is driving yet.
+func (handler *HTTPHandler) receive() {
+	for level := range handler.levels {
+		if handler.latest == nil {
+			handler.inputs <- &game.Input{Input: game.Leave, Player: level.Player.ID}
+		}
+		handler.mu.Lock()
+		handler.latest = level
+		close(handler.updated)
+		handler.updated = make(chan struct{})
+		handler.mu.Unlock()
+	}
+}
+
+func (handler *HTTPHandler) current() (*game.Level, chan struct{}) {
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	return handler.latest, handler.updated
+}
+
+func (handler *HTTPHandler) writeLevel(w http.ResponseWriter, level *game.Level, since int) {
+	if level == nil {
+		http.Error(w, "the game has not started yet", http.StatusServiceUnavailable)
+		return
+	}
+	if since < 0 || since > len(level.Log.Entries) {
+		since = len(level.Log.Entries)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(visibleLevel(newLevelMessage(level, since)))
+}
+
+func (handler *HTTPHandler) getLevel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	since, _ := strconv.Atoi(r.URL.Query().Get("since"))
+	level, _ := handler.current()
+	handler.writeLevel(w, level, since)
+}
+
+func (handler *HTTPHandler) postAction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var msg inputMessage
+	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch msg.Input {
+	case game.None, game.Quit, game.CloseWindow, game.Join, game.Leave:
+		http.Error(w, "not an action a player can take", http.StatusBadRequest)
+		return
+	}
+
+	level, updated := handler.current()
+	if level == nil {
+		http.Error(w, "the game has not started yet", http.StatusServiceUnavailable)
+		return
+	}
+	since := len(level.Log.Entries)
+	if !handler.act(msg.input(level.Player.ID)) {
+		http.Error(w, "the game is not taking actions", http.StatusServiceUnavailable)
+		return
+	}
+	select {
+	case <-updated:
+	case <-time.After(actionTimeout):
+	}
+	level, _ = handler.current()
+	handler.writeLevel(w, level, since)
+}
+
+func (handler *HTTPHandler) send(input *game.Input) bool {
+	select {
+	case handler.inputs <- input:
+		return true
+	case <-time.After(actionTimeout):
+		return false
+	}
+}
+
+// act joins the game if the player is away, plays input and has the
+// player leave again if no other action follows in time.
+func (handler *HTTPHandler) act(input *game.Input) bool {
+	handler.playing.Lock()
+	defer handler.playing.Unlock()
+	if !handler.joined {
+		if !handler.send(&game.Input{Input: game.Join, Player: input.Player}) {
+			return false
+		}
+		handler.joined = true
+	}
+	if !handler.send(input) {
+		return false
+	}
+	handler.actions++
+	actions := handler.actions
+	time.AfterFunc(actionTimeout, func() {
+		handler.leave(input.Player, actions)
+	})
+	return true
+}
+
+func (handler *HTTPHandler) leave(player, actions int) {
+	handler.playing.Lock()
+	defer handler.playing.Unlock()
+	if handler.joined && handler.actions == actions && handler.send(&game.Input{Input: game.Leave, Player: player}) {
+		handler.joined = false
+	}
+}
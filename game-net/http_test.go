@@ -0,0 +1,99 @@
+package gamenet
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmadfarhanstwn/rpg/game-logic"
+)
+
+func decodeLevel(t *testing.T, resp *http.Response) (levelMessage, game.Pos) {
+	t.Helper()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("got status %s", resp.Status)
+	}
+	var msg levelMessage
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	for _, player := range msg.Players {
+		if player.ID == msg.Player {
+			return msg, player.Pos
+		}
+	}
+	t.Fatal("the level does not hold the player")
+	return msg, game.Pos{}
+}
+
+// playAlone has the window's player move left and back, which it can only
+// do while nobody else holds up the round. The moves are sent again until
+// the game lets them through.
+func playAlone(t *testing.T, g *game.Game) {
+	t.Helper()
+	level := nextLevel(t, g.LevelChan[0], anyLevel)
+	start := level.Player.Pos
+	left := game.Pos{X: start.X - 1, Y: start.Y}
+	for _, step := range []struct {
+		input game.InputState
+		pos   game.Pos
+	}{{game.Left, left}, {game.Right, start}} {
+		g.InputChan <- &game.Input{Input: step.input}
+		timeout := time.After(5 * time.Second)
+		for moved := false; !moved; {
+			select {
+			case level := <-g.LevelChan[0]:
+				moved = level.Player.Pos == step.pos
+			case <-time.After(100 * time.Millisecond):
+				g.InputChan <- &game.Input{Input: step.input}
+			case <-timeout:
+				t.Fatal("the window's player is kept waiting")
+			}
+		}
+	}
+}
+
+func TestHTTPLoopback(t *testing.T) {
+	g := game.NewGame(2)
+	go g.Run()
+	server := httptest.NewServer(NewHTTPHandler(g.LevelChan[1], g.InputChan))
+	defer server.Close()
+
+	var resp *http.Response
+	for tries := 0; ; tries++ {
+		var err error
+		if resp, err = http.Get(server.URL + "/level"); err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode == http.StatusOK || tries == 50 {
+			break
+		}
+		resp.Body.Close()
+		time.Sleep(10 * time.Millisecond)
+	}
+	msg, start := decodeLevel(t, resp)
+	if msg.Player != 1 || len(msg.Map) == 0 {
+		t.Fatalf("GET /level gave player %d and %d rows", msg.Player, len(msg.Map))
+	}
+
+	// The script has not acted yet, so it holds nobody up.
+	playAlone(t, g)
+
+	body, _ := json.Marshal(inputMessage{Input: game.Left})
+	resp, err := http.Post(server.URL+"/action", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, pos := decodeLevel(t, resp); pos != (game.Pos{X: start.X - 1, Y: start.Y}) {
+		t.Errorf("POST /action moved the player from %v to %v", start, pos)
+	}
+
+	// The script stopped acting, so it leaves again.
+	time.Sleep(actionTimeout)
+	playAlone(t, g)
+	g.InputChan <- &game.Input{Input: game.Quit}
+}
@@ -0,0 +1,208 @@
+// Package gamenet lets players join a Game over TCP.
+//
+// The protocol is newline separated JSON, one message per line. A client
+// starts with a helloMessage saying whether it plays or only watches, and
+// a player then sends inputMessage values: the game.InputState to
+// perform and, for item actions, the id of the item and the quick slot.
+// Quit and CloseWindow end the connection. Spectators send nothing more
+// and leave by closing the connection. The server answers every turn
+// with a levelMessage holding the level as the client's player, or the
+// spectator, sees it, leaving out tiles they never saw and what is out of
+// their sight. Maps keyed by position are sent as lists, and the message
+// log only carries the entries the client has not been sent yet.
+package gamenet
+
+import (
+	"github.com/ahmadfarhanstwn/rpg/game-logic"
+)
+
+type helloMessage struct {
+	Spectate bool `json:"spectate,omitempty"`
+	FullView bool `json:"fullView,omitempty"`
+}
+
+type inputMessage struct {
+	Input  game.InputState `json:"input"`
+	Item   int64           `json:"item,omitempty"`
+	Slot   int             `json:"slot,omitempty"`
+	Target *game.Pos       `json:"target,omitempty"`
+}
+
+type itemStack struct {
+	Pos   game.Pos      `json:"pos"`
+	Items []*game.Items `json:"items"`
+}
+
+type lock struct {
+	Pos game.Pos `json:"pos"`
+	ID  string   `json:"id"`
+}
+
+type levelMessage struct {
+	Player     int             `json:"player"`
+	Players    []*game.Player  `json:"players"`
+	Map        [][]game.Tile   `json:"map"`
+	Monsters   []*game.Monster `json:"monsters"`
+	Items      []itemStack     `json:"items"`
+	Portals    []game.Pos      `json:"portals"`
+	Locks      []lock          `json:"locks"`
+	Traps      []*game.Trap    `json:"traps"`
+	Diagonal   bool            `json:"diagonal"`
+	Spectator  bool            `json:"spectator,omitempty"`
+	Turn       int             `json:"turn"`
+	Log        []game.Event    `json:"log"`
+	TurnEvents []game.Event    `json:"turnEvents"`
+}
+
+func newInputMessage(input *game.Input) inputMessage {
+	msg := inputMessage{Input: input.Input, Slot: input.Slot}
+	if input.Item != nil {
+		msg.Item = input.Item.ID
+	}
+	if input.Input == game.Travel || input.Input == game.Fire || input.Input == game.Cast {
+		target := input.Target
+		msg.Target = &target
+	}
+	return msg
+}
+
+func (msg inputMessage) input(player int) *game.Input {
+	input := &game.Input{Input: msg.Input, Player: player, Slot: msg.Slot}
+	if msg.Item != 0 {
+		input.Item = &game.Items{ID: msg.Item}
+	}
+	if msg.Target != nil {
+		input.Target = *msg.Target
+	}
+	return input
+}
+
+// newLevelMessage encodes a snapshot, leaving out the first logged entries
+// that were already sent.
+func newLevelMessage(level *game.Level, logSent int) levelMessage {
+	msg := levelMessage{
+		Player:     level.Player.ID,
+		Players:    level.Players,
+		Map:        level.Map,
+		Diagonal:   level.Diagonal,
+		Spectator:  level.Spectator,
+		Turn:       level.Log.Turn,
+		Log:        level.Log.Entries[logSent:],
+		TurnEvents: level.TurnEvents,
+	}
+	for _, monster := range level.Monsters {
+		msg.Monsters = append(msg.Monsters, monster)
+	}
+	for pos, items := range level.Items {
+		msg.Items = append(msg.Items, itemStack{pos, items})
+	}
+	for pos := range level.Portals {
+		msg.Portals = append(msg.Portals, pos)
+	}
+	for pos, id := range level.Locks {
+		msg.Locks = append(msg.Locks, lock{pos, id})
+	}
+	for _, trap := range level.Traps {
+		msg.Traps = append(msg.Traps, trap)
+	}
+	return msg
+}
+
+// level rebuilds the snapshot, adding the new entries to the client's log.
+func (msg levelMessage) level(log *game.MessageLog) *game.Level {
+	log.Turn = msg.Turn
+	log.Entries = append(log.Entries, msg.Log...)
+
+	level := &game.Level{
+		Map:        msg.Map,
+		Log:        &game.MessageLog{Turn: log.Turn, Entries: log.Entries[:len(log.Entries):len(log.Entries)]},
+		TurnEvents: msg.TurnEvents,
+		Players:    msg.Players,
+		Monsters:   make(map[game.Pos]*game.Monster, len(msg.Monsters)),
+		Portals:    make(map[game.Pos]*game.LevelPos, len(msg.Portals)),
+		Items:      make(map[game.Pos][]*game.Items, len(msg.Items)),
+		Locks:      make(map[game.Pos]string, len(msg.Locks)),
+		Traps:      make(map[game.Pos]*game.Trap, len(msg.Traps)),
+		Debug:      make(map[game.Pos]bool),
+		Diagonal:   msg.Diagonal,
+		Spectator:  msg.Spectator,
+	}
+	for _, player := range msg.Players {
+		if player.ID == msg.Player {
+			level.Player = player
+		}
+	}
+	for _, monster := range msg.Monsters {
+		level.Monsters[monster.Pos] = monster
+	}
+	for _, stack := range msg.Items {
+		level.Items[stack.Pos] = stack.Items
+	}
+	for _, pos := range msg.Portals {
+		level.Portals[pos] = &game.LevelPos{Pos: pos}
+	}
+	for _, lock := range msg.Locks {
+		level.Locks[lock.Pos] = lock.ID
+	}
+	for _, trap := range msg.Traps {
+		level.Traps[trap.Pos] = trap
+	}
+	return level
+}
+
+// visibleLevel leaves out what the player could not know about.
+func visibleLevel(msg levelMessage) levelMessage {
+	visible := func(pos game.Pos) bool {
+		return msg.Map[pos.Y][pos.X].Visible
+	}
+
+	tiles := make([][]game.Tile, len(msg.Map))
+	for y := range msg.Map {
+		tiles[y] = make([]game.Tile, len(msg.Map[y]))
+		for x, tile := range msg.Map[y] {
+			if tile.Seen {
+				tiles[y][x] = tile
+			}
+		}
+	}
+
+	var monsters []*game.Monster
+	for _, monster := range msg.Monsters {
+		if visible(monster.Pos) {
+			monsters = append(monsters, monster)
+		}
+	}
+	var items []itemStack
+	for _, stack := range msg.Items {
+		if visible(stack.Pos) {
+			items = append(items, stack)
+		}
+	}
+	var portals []game.Pos
+	for _, pos := range msg.Portals {
+		if msg.Map[pos.Y][pos.X].Seen {
+			portals = append(portals, pos)
+		}
+	}
+	var locks []lock
+	for _, l := range msg.Locks {
+		if msg.Map[l.Pos.Y][l.Pos.X].Seen {
+			locks = append(locks, l)
+		}
+	}
+	var traps []*game.Trap
+	for _, trap := range msg.Traps {
+		if !trap.Hidden && msg.Map[trap.Pos.Y][trap.Pos.X].Seen {
+			traps = append(traps, trap)
+		}
+	}
+	var players []*game.Player
+	for _, player := range msg.Players {
+		if player.ID == msg.Player || visible(player.Pos) {
+			players = append(players, player)
+		}
+	}
+
+	msg.Map, msg.Monsters, msg.Items, msg.Portals, msg.Locks, msg.Traps, msg.Players = tiles, monsters, items, portals, locks, traps, players
+	return msg
+}
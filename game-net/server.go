@@ -0,0 +1,180 @@
+package gamenet
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ahmadfarhanstwn/rpg/game-logic"
+)
+
+// Server hands every window of a Game to a remote client. Each window's
+// player waits out of the game until a client joins as them. Spectators
+// can come and go as they like.
+type Server struct {
+	game  *game.Game
+	slots []*slot
+	mu    sync.Mutex
+}
+
+// writeTimeout is how long a client may take to read a level before it is
+// dropped.
+const writeTimeout = 5 * time.Second
+
+// A slot's out channel only ever holds the latest level, so a client that
+// reads slowly skips levels instead of holding up the others.
+type slot struct {
+	player int
+	levels chan *game.Level
+	conn   net.Conn
+	out    chan *game.Level
+	latest *game.Level
+}
+
+func NewServer(g *game.Game) *Server {
+	server := &Server{game: g}
+	for i, levels := range g.LevelChan {
+		sl := &slot{player: i, levels: levels}
+		server.slots = append(server.slots, sl)
+		go server.forward(sl)
+	}
+	return server
+}
+
+// Serve accepts clients on listener until it fails. It needs the game to
+// be running.
+func (server *Server) Serve(listener net.Listener) error {
+	for _, sl := range server.slots {
+		server.game.InputChan <- &game.Input{Input: game.Leave, Player: sl.player}
+	}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go server.join(conn)
+	}
+}
+
+// forward keeps draining the window so the game never waits on a player
+// nobody is connected as.
+func (server *Server) forward(sl *slot) {
+	for level := range sl.levels {
+		server.mu.Lock()
+		sl.latest = level
+		if sl.out != nil {
+			offer(sl.out, level)
+		}
+		server.mu.Unlock()
+	}
+}
+
+// offer replaces whatever level out still holds. Only one goroutine may
+// offer to out at a time.
+func offer(out chan *game.Level, level *game.Level) {
+	select {
+	case <-out:
+	default:
+	}
+	out <- level
+}
+
+// writeLevels writes every level it is given to conn until it is closed,
+// and drops the client if a write fails or takes too long.
+func writeLevels(conn net.Conn, levels chan *game.Level, fullView bool) {
+	writer := bufio.NewWriter(conn)
+	logSent := 0
+	for level := range levels {
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := writeLevel(writer, level, logSent, fullView); err != nil {
+			conn.Close()
+			return
+		}
+		logSent = len(level.Log.Entries)
+	}
+}
+
+// writeLevel sends the level leaving out what the client can't see, unless
+// fullView lets it see everything.
+func writeLevel(writer *bufio.Writer, level *game.Level, logSent int, fullView bool) error {
+	msg := newLevelMessage(level, logSent)
+	if !fullView {
+		msg = visibleLevel(msg)
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	writer.Write(data)
+	writer.WriteByte('\n')
+	return writer.Flush()
+}
+
+func (server *Server) join(conn net.Conn) {
+	decoder := json.NewDecoder(conn)
+	var hello helloMessage
+	if err := decoder.Decode(&hello); err != nil {
+		conn.Close()
+		return
+	}
+	if hello.Spectate {
+		server.spectate(conn, decoder, hello.FullView)
+		return
+	}
+
+	server.mu.Lock()
+	var sl *slot
+	for _, candidate := range server.slots {
+		if candidate.conn == nil {
+			sl = candidate
+			break
+		}
+	}
+	if sl == nil {
+		server.mu.Unlock()
+		conn.Close()
+		return
+	}
+	sl.conn = conn
+	sl.out = make(chan *game.Level, 1)
+	if sl.latest != nil {
+		offer(sl.out, sl.latest)
+	}
+	go writeLevels(conn, sl.out, false)
+	server.mu.Unlock()
+
+	server.game.InputChan <- &game.Input{Input: game.Join, Player: sl.player}
+	for {
+		var msg inputMessage
+		if err := decoder.Decode(&msg); err != nil {
+			break
+		}
+		if msg.Input == game.Quit || msg.Input == game.CloseWindow || msg.Input == game.Join || msg.Input == game.Leave {
+			break
+		}
+		server.game.InputChan <- msg.input(sl.player)
+	}
+
+	server.mu.Lock()
+	close(sl.out)
+	sl.conn = nil
+	sl.out = nil
+	server.mu.Unlock()
+	conn.Close()
+	server.game.InputChan <- &game.Input{Input: game.Leave, Player: sl.player}
+}
+
+func (server *Server) spectate(conn net.Conn, decoder *json.Decoder, fullView bool) {
+	spectator := server.game.AddSpectator(fullView)
+	go func() {
+		var msg json.RawMessage
+		for decoder.Decode(&msg) == nil {
+		}
+		server.game.RemoveSpectator(spectator)
+	}()
+
+	writeLevels(conn, spectator.LevelChan, fullView)
+	conn.Close()
+}
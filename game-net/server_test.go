@@ -0,0 +1,185 @@
+package gamenet
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ahmadfarhanstwn/rpg/game-logic"
+)
+
+// The maps are loaded relative to the repository root.
+func TestMain(m *testing.M) {
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// nextLevel waits for a level the client is sent that satisfies done.
+func nextLevel(t *testing.T, levels chan *game.Level, done func(*game.Level) bool) *game.Level {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case level, ok := <-levels:
+			if !ok {
+				t.Fatal("connection closed")
+			}
+			if done(level) {
+				return level
+			}
+		case <-timeout:
+			t.Fatal("timed out waiting for a level")
+		}
+	}
+}
+
+func anyLevel(*game.Level) bool { return true }
+
+func playerAt(pos game.Pos) func(*game.Level) bool {
+	return func(level *game.Level) bool { return level.Player.Pos == pos }
+}
+
+func TestServeLoopback(t *testing.T) {
+	g := game.NewGame(2)
+	go g.Run()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go NewServer(g).Serve(listener)
+	addr := listener.Addr().String()
+
+	first, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go first.Run()
+	level := nextLevel(t, first.LevelChan, anyLevel)
+	if level.Player == nil || level.Player.ID != 0 {
+		t.Fatalf("first client plays %v, want player 0", level.Player)
+	}
+	if len(level.Map) == 0 || level.Spectator {
+		t.Fatal("first client was not sent the level")
+	}
+	checkVisible(t, level)
+
+	// The second window's player waits out of the game, so the first
+	// one can keep moving on their own.
+	start := level.Player.Pos
+	left := game.Pos{X: start.X - 1, Y: start.Y}
+	first.InputChan <- &game.Input{Input: game.Left}
+	nextLevel(t, first.LevelChan, playerAt(left))
+	first.InputChan <- &game.Input{Input: game.Right}
+	nextLevel(t, first.LevelChan, playerAt(start))
+
+	second, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go second.Run()
+	if level := nextLevel(t, second.LevelChan, anyLevel); level.Player.ID != 1 {
+		t.Fatalf("second client plays player %d, want player 1", level.Player.ID)
+	}
+
+	// Once the second player joins, the round waits for both of them.
+	first.InputChan <- &game.Input{Input: game.Left}
+	nextLevel(t, first.LevelChan, playerAt(left))
+	second.InputChan <- &game.Input{Input: game.Search}
+	nextLevel(t, second.LevelChan, anyLevel)
+
+	// After the second player leaves, the first plays on alone again. The
+	// move is sent again until the server has taken the second one out of
+	// the round.
+	second.InputChan <- &game.Input{Input: game.CloseWindow}
+	sendUntil(t, first, game.Right, first.LevelChan, playerAt(start))
+
+	spectator, err := DialSpectator(addr, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go spectator.Run()
+	level = sendUntil(t, first, game.Search, spectator.LevelChan, anyLevel)
+	if !level.Spectator {
+		t.Error("spectator was sent a player's level")
+	}
+	first.InputChan <- &game.Input{Input: game.Quit}
+}
+
+// sendUntil sends input for client again and again until a level that
+// satisfies done comes in on levels, for when the server may not be ready
+// for it yet.
+func sendUntil(t *testing.T, client *Client, input game.InputState, levels chan *game.Level, done func(*game.Level) bool) *game.Level {
+	t.Helper()
+	client.InputChan <- &game.Input{Input: input}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case level := <-levels:
+			if done(level) {
+				return level
+			}
+		case <-time.After(100 * time.Millisecond):
+			client.InputChan <- &game.Input{Input: input}
+		case <-timeout:
+			t.Fatal("timed out waiting for a level")
+		}
+	}
+}
+
+// checkVisible fails if the level holds anything the player can't see.
+func checkVisible(t *testing.T, level *game.Level) {
+	t.Helper()
+	for y, row := range level.Map {
+		for x, tile := range row {
+			if !tile.Seen && tile.Rune != game.Blank {
+				t.Fatalf("unseen tile at %d,%d was sent", x, y)
+			}
+		}
+	}
+	for pos := range level.Monsters {
+		if !level.Map[pos.Y][pos.X].Visible {
+			t.Fatalf("monster out of sight at %v was sent", pos)
+		}
+	}
+	for pos, trap := range level.Traps {
+		if trap.Hidden {
+			t.Fatalf("hidden trap at %v was sent", pos)
+		}
+	}
+}
+
+func TestStalledClientHoldsNobodyUp(t *testing.T) {
+	g := game.NewGame(2)
+	go g.Run()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	server := NewServer(g)
+	go server.Serve(listener)
+
+	// Writes to a pipe block until they are read, and this client never
+	// reads.
+	stalled, serverSide := net.Pipe()
+	defer stalled.Close()
+	go server.join(serverSide)
+	if _, err := stalled.Write([]byte("{}\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := Dial(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go client.Run()
+	start := nextLevel(t, client.LevelChan, anyLevel).Player.Pos
+	left := game.Pos{X: start.X - 1, Y: start.Y}
+	client.InputChan <- &game.Input{Input: game.Left}
+	nextLevel(t, client.LevelChan, playerAt(left))
+	client.InputChan <- &game.Input{Input: game.Quit}
+}
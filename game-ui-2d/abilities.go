@@ -0,0 +1,52 @@
+package ui2d
+
+import (
+	"strconv"
+
+	"github.com/ahmadfarhanstwn/rpg/game-logic"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const abilityKeys = 9
+
+func (ui *ui) abilityPressed() int {
+	for i := 0; i < abilityKeys; i++ {
+		if ui.keyPressedOnce(uint8(sdl.SCANCODE_F1 + i)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// drawAbilities lists the player's mana and the abilities they know with
+// their hotkeys, greyed out while they can't be cast.
+func (ui *ui) drawAbilities(level *game.Level, x, y int32) {
+	player := level.Player
+	lines := []string{"Mana : " + strconv.Itoa(player.Mana) + "/" + strconv.Itoa(player.MaxMana)}
+	colors := []sdl.Color{{255,255,255,0}}
+	for i, ability := range player.Abilities {
+		if i >= abilityKeys {
+			break
+		}
+		def := game.AbilityDefinition(ability.Name)
+		line := "F" + strconv.Itoa(i+1) + " " + ability.Name + " (" + strconv.Itoa(def.Mana) + ")"
+		color := sdl.Color{255,255,255,0}
+		if ability.Cooldown > 0 {
+			line += " " + strconv.Itoa(ability.Cooldown)
+			color = sdl.Color{128,128,128,0}
+		} else if player.Mana < def.Mana {
+			color = sdl.Color{128,128,128,0}
+		}
+		lines = append(lines, line)
+		colors = append(colors, color)
+	}
+	for i, line := range lines {
+		tex := ui.stringToFont(line, mediumSize, colors[i])
+		_,_,w,h,err := tex.Query()
+		if err != nil {
+			panic(err)
+		}
+		ui.renderer.Copy(tex, nil, &sdl.Rect{x,y,w,h})
+		y += h
+	}
+}
@@ -2,6 +2,7 @@ package ui2d
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/ahmadfarhanstwn/rpg/game-logic"
 	"github.com/veandco/go-sdl2/sdl"
@@ -16,10 +17,32 @@ func (ui *ui) getInventoryRect() *sdl.Rect {
 	return &sdl.Rect{offsetX, offsetY, invWidth, invHeight}
 }
 
+func (ui *ui) getInventoryColumns() int32 {
+	invRect := ui.getInventoryRect()
+	itemSize := int32(itemSizeRatio * float32(ui.winWidth))
+	return (invRect.W-20)/itemSize
+}
+
 func (ui *ui) getInventoryItemRect(i int) *sdl.Rect {
 	invRect := ui.getInventoryRect()
-	itemSize := itemSizeRatio * float32(ui.winWidth)
-	return  &sdl.Rect{10+invRect.X+int32(i)*int32(itemSize), invRect.Y+invRect.H-int32(itemSize),int32(itemSize),int32(itemSize)}
+	itemSize := int32(itemSizeRatio * float32(ui.winWidth))
+	cols := ui.getInventoryColumns()
+	rows := (int32(game.InventorySlots)+cols-1)/cols
+	col := int32(i)%cols
+	row := int32(i)/cols
+	return &sdl.Rect{10+invRect.X+col*itemSize, invRect.Y+invRect.H-(rows-row)*itemSize, itemSize, itemSize}
+}
+
+func (ui *ui) drawStackCount(item *game.Items, rect *sdl.Rect) {
+	if item.Count <= 1 {
+		return
+	}
+	tex := ui.stringToFont(strconv.Itoa(item.Count), mediumSize, sdl.Color{255,255,255,0})
+	_,_,w,h,err := tex.Query()
+	if err != nil {
+		panic(err)
+	}
+	ui.renderer.Copy(tex, nil, &sdl.Rect{rect.X+rect.W-w, rect.Y+rect.H-h, w, h})
 }
 
 func(ui *ui) DrawInventory(level *game.Level) {
@@ -46,15 +69,116 @@ func(ui *ui) DrawInventory(level *game.Level) {
 	if level.Player.Armour != nil {
 		ui.renderer.Copy(ui.imageAtlas, &ui.textureIndex[level.Player.Armour.Rune][0], ui.getArmorSlotRect())
 	}
+	ui.renderer.Copy(ui.swordSlotBackground, nil, ui.getRangedSlotRect())
+	if level.Player.Ranged != nil {
+		ui.renderer.Copy(ui.imageAtlas, &ui.textureIndex[level.Player.Ranged.Rune][0], ui.getRangedSlotRect())
+	}
+
+	for i := 0; i < game.InventorySlots; i++ {
+		slotRect := ui.getInventoryItemRect(i)
+		if i == ui.selectedItem {
+			ui.renderer.Copy(ui.inventoryBorder, nil, slotRect)
+			slotRect = &sdl.Rect{slotRect.X+2, slotRect.Y+2, slotRect.W-4, slotRect.H-4}
+		}
+		ui.renderer.Copy(ui.characterSlotBackground, nil, slotRect)
+	}
 
 	for i, item := range level.Player.Items {
 		itemSrcRect := ui.textureIndex[item.Rune][0]
-		if item == ui.draggedItem {
+		if ui.draggedItem != nil && item.ID == ui.draggedItem.ID {
 			itemSize := itemSizeRatio * float32(ui.winWidth)
 			ui.renderer.Copy(ui.imageAtlas, &itemSrcRect,&sdl.Rect{int32(ui.currMouseState.pos.X)-(int32(itemSize/2)), int32(ui.currMouseState.pos.Y)-(int32(itemSize/2)), int32(itemSize), int32(itemSize)})
 		} else {
-			ui.renderer.Copy(ui.imageAtlas, &itemSrcRect,ui.getInventoryItemRect(i))
+			itemRect := ui.getInventoryItemRect(i)
+			ui.renderer.Copy(ui.imageAtlas, &itemSrcRect, itemRect)
+			ui.drawStackCount(item, itemRect)
+		}
+	}
+
+	ui.drawSelectedItem(level)
+}
+
+func (ui *ui) getSelectedItem(level *game.Level) *game.Items {
+	if ui.selectedItem < 0 || ui.selectedItem >= len(level.Player.Items) {
+		return nil
+	}
+	return level.Player.Items[ui.selectedItem]
+}
+
+func (ui *ui) drawSelectedItem(level *game.Level) {
+	item := ui.getSelectedItem(level)
+	if item == nil {
+		return
+	}
+	invRect := ui.getInventoryRect()
+	lines := []string{item.Name}
+	if ui.inspecting {
+		lines = append(lines, item.Description())
+		if item.Count > 1 {
+			lines = append(lines, "You carry "+strconv.Itoa(item.Count))
+		}
+		lines = append(lines, "E : equip   U : use   T : throw   X : drop   1-9 : quick slot   Space : close")
+	}
+	y := ui.getInventoryItemRect(0).Y
+	for i := len(lines)-1; i >= 0; i-- {
+		tex := ui.stringToFont(lines[i], mediumSize, sdl.Color{255,255,255,0})
+		_,_,w,h,err := tex.Query()
+		if err != nil {
+			panic(err)
 		}
+		y -= h
+		ui.renderer.Copy(tex, nil, &sdl.Rect{invRect.X+10, y, w, h})
+	}
+}
+
+func (ui *ui) checkInventoryKeys(input *game.Input, level *game.Level) {
+	cols := int(ui.getInventoryColumns())
+	selected := ui.selectedItem
+	if ui.keyPressedOnce(sdl.SCANCODE_UP) || ui.keyPressedOnce(sdl.SCANCODE_W) {
+		selected -= cols
+	}
+	if ui.keyPressedOnce(sdl.SCANCODE_DOWN) || ui.keyPressedOnce(sdl.SCANCODE_S) {
+		selected += cols
+	}
+	if ui.keyPressedOnce(sdl.SCANCODE_LEFT) || ui.keyPressedOnce(sdl.SCANCODE_A) {
+		selected--
+	}
+	if ui.keyPressedOnce(sdl.SCANCODE_RIGHT) || ui.keyPressedOnce(sdl.SCANCODE_D) {
+		selected++
+	}
+	if selected >= 0 && selected < game.InventorySlots {
+		ui.selectedItem = selected
+	}
+
+	if ui.keyPressedOnce(sdl.SCANCODE_SPACE) {
+		ui.inspecting = !ui.inspecting
+	}
+
+	item := ui.getSelectedItem(level)
+	if item == nil {
+		return
+	}
+	if ui.keyPressedOnce(sdl.SCANCODE_E) && item.Equippable() {
+		input.Input = game.EquipItem
+		input.Item = item
+	}
+	if ui.keyPressedOnce(sdl.SCANCODE_U) || ui.keyPressedOnce(sdl.SCANCODE_RETURN) {
+		if item.Usable() {
+			input.Input = game.UseItem
+			input.Item = item
+		}
+	}
+	if ui.keyPressedOnce(sdl.SCANCODE_X) {
+		input.Input = game.DropItem
+		input.Item = item
+	}
+	if ui.keyPressedOnce(sdl.SCANCODE_T) && item.Type == game.Thrown {
+		ui.startTargeting(level, item)
+	}
+	if slot := ui.quickSlotPressed(); slot != -1 {
+		input.Input = game.AssignQuickSlot
+		input.Item = item
+		input.Slot = slot
 	}
 }
 
@@ -74,6 +198,11 @@ func (ui *ui) CheckEquippedItem() *game.Items {
 		if r.HasIntersection(&sdl.Rect{int32(ui.currMouseState.pos.X), int32(ui.currMouseState.pos.Y), 1, 1}) {
 			return ui.draggedItem
 		}
+	} else if ui.draggedItem.Type == game.Launcher {
+		r := ui.getRangedSlotRect()
+		if r.HasIntersection(&sdl.Rect{int32(ui.currMouseState.pos.X), int32(ui.currMouseState.pos.Y), 1, 1}) {
+			return ui.draggedItem
+		}
 	}
 	return nil
 }
@@ -103,6 +232,11 @@ func (ui *ui) getArmorSlotRect() *sdl.Rect {
 	return &sdl.Rect{332,150, int32(itemSize), int32(itemSize)}
 }
 
+func (ui *ui) getRangedSlotRect() *sdl.Rect {
+	itemSize := itemSizeRatio * float32(ui.winWidth) * 1.05
+	return &sdl.Rect{540,248, int32(itemSize), int32(itemSize)}
+}
+
 func (ui *ui) getBackgroundRect(index int) *sdl.Rect {
 	itemSize := itemSizeRatio * float32(ui.winWidth)
 	return &sdl.Rect{int32(ui.winWidth-int(itemSize)-(int(itemSize)*index)),int32(ui.winHeight-int(itemSize)),int32(itemSize),int32(itemSize)}
@@ -122,20 +256,19 @@ func (ui *ui) CheckInventoryItems(level *game.Level) *game.Items {
 	return nil
 }
 
-func (ui *ui) DrinkPotion(level *game.Level) {
-	if ui.currMouseState.rightButton {
+func (ui *ui) DrinkPotion(level *game.Level) *game.Items {
+	if !ui.currMouseState.rightButton && ui.prevMouseState.rightButton {
 		mousePos := ui.currMouseState.pos
 		for i, item := range level.Player.Items {
-			if item.Type == game.Potion {
+			if item.Usable() {
 				itemRect := ui.getInventoryItemRect(i)
 				if itemRect.HasIntersection(&sdl.Rect{int32(mousePos.X), int32(mousePos.Y),1,1}) {
-					level.Player.Items = append(level.Player.Items[:i], level.Player.Items[i+1:]...)
-					level.Player.Character.Hp += int(item.Power)
-					playRandomSounds(ui.burpSound, 100)
+					return item
 				}
 			}
 		}
 	}
+	return nil
 }
 
 func (ui *ui) CheckBackgroundItems(level *game.Level) *game.Items {
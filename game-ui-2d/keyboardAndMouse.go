@@ -31,43 +31,138 @@ func (ui *ui) keyPressed(key uint8) bool {
 	return ui.keyboardState[key] == 0 && ui.prevKeyboardState[key] == 1
 }
 
-func (ui *ui) checkInput(input game.Input) {
-	if sdl.GetKeyboardFocus() == ui.window || sdl.GetMouseFocus() == ui.window {
-		if ui.keyPressedOnce(sdl.SCANCODE_UP) || ui.keyPressedOnce(sdl.SCANCODE_W) {
-			input.Input = game.Up
-		}
-		if ui.keyPressedOnce(sdl.SCANCODE_LEFT) || ui.keyPressedOnce(sdl.SCANCODE_A) {
-			input.Input = game.Left
-		}
-		if ui.keyPressedOnce(sdl.SCANCODE_RIGHT) || ui.keyPressedOnce(sdl.SCANCODE_D) {
-			input.Input = game.Right
+func (ui *ui) quickSlotPressed() int {
+	for i := 0; i < game.QuickSlotCount; i++ {
+		if ui.keyPressedOnce(uint8(sdl.SCANCODE_1 + i)) {
+			return i
 		}
-		if ui.keyPressedOnce(sdl.SCANCODE_DOWN) || ui.keyPressedOnce(sdl.SCANCODE_S) {
-			input.Input = game.Down
+	}
+	return -1
+}
+
+// CheckMapClick returns the seen tile under the mouse when the left button
+// is released over the map.
+func (ui *ui) CheckMapClick(level *game.Level) (game.Pos, bool) {
+	if ui.currMouseState.leftButton || !ui.prevMouseState.leftButton {
+		return game.Pos{}, false
+	}
+	offsetX, offsetY := ui.mapOffset()
+	mousePos := ui.currMouseState.pos
+	if mousePos.X < offsetX || mousePos.Y < offsetY {
+		return game.Pos{}, false
+	}
+	pos := game.Pos{(mousePos.X-offsetX)/32, (mousePos.Y-offsetY)/32}
+	if pos.Y >= len(level.Map) || pos.X >= len(level.Map[pos.Y]) || !level.Map[pos.Y][pos.X].Seen {
+		return game.Pos{}, false
+	}
+	return pos, true
+}
+
+func (ui *ui) checkInput(input game.Input, level *game.Level) {
+	if sdl.GetKeyboardFocus() == ui.window || sdl.GetMouseFocus() == ui.window {
+		if ui.state == InventoryUI {
+			ui.checkInventoryKeys(&input, level)
+		} else if ui.state == TargetUI {
+			ui.checkTargetKeys(&input, level)
+		} else if ui.state == LogUI {
+			if ui.keyPressedOnce(sdl.SCANCODE_PAGEUP) {
+				ui.scrollLog(ui.logVisible)
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_PAGEDOWN) {
+				ui.scrollLog(-ui.logVisible)
+			}
+		} else {
+			if ui.keyPressedOnce(sdl.SCANCODE_UP) || ui.keyPressedOnce(sdl.SCANCODE_W) {
+				input.Input = game.Up
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_LEFT) || ui.keyPressedOnce(sdl.SCANCODE_A) {
+				input.Input = game.Left
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_RIGHT) || ui.keyPressedOnce(sdl.SCANCODE_D) {
+				input.Input = game.Right
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_DOWN) || ui.keyPressedOnce(sdl.SCANCODE_S) {
+				input.Input = game.Down
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_KP_7) {
+				input.Input = game.UpLeft
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_KP_9) {
+				input.Input = game.UpRight
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_KP_1) {
+				input.Input = game.DownLeft
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_KP_3) {
+				input.Input = game.DownRight
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_T) {
+				input.Input = game.TakeAllItems
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_Q) {
+				input.Input = game.Search
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_O) {
+				input.Input = game.Explore
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_G) {
+				input.Input = game.TravelToStairs
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_C) {
+				input.Input = game.TravelToCoin
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_R) {
+				input.Input = game.Rest
+			}
+			if ui.keyPressedOnce(sdl.SCANCODE_F) {
+				ui.startTargeting(level, nil)
+			}
+			if slot := ui.abilityPressed(); slot != -1 && slot < len(level.Player.Abilities) {
+				ui.startCasting(&input, level, slot)
+			}
+			if slot := ui.quickSlotPressed(); slot != -1 {
+				item := level.Player.QuickSlotItem(slot)
+				if item != nil && item.Usable() {
+					input.Input = game.UseItem
+					input.Item = item
+				} else if item != nil && item.Equippable() {
+					input.Input = game.EquipItem
+					input.Item = item
+				}
+			}
 		}
-		if ui.keyPressedOnce(sdl.SCANCODE_T) {
-			input.Input = game.TakeAllItems
+		if ui.keyPressedOnce(sdl.SCANCODE_L) {
+			if ui.state == LogUI {
+				ui.state = MainUI
+			} else {
+				ui.state = LogUI
+				ui.logScroll = 0
+			}
 		}
 		if ui.keyPressedOnce(sdl.SCANCODE_I) {
-			if ui.state == MainUI {
+			if ui.state != InventoryUI {
 				ui.state = InventoryUI
+				ui.selectedItem = 0
+				ui.inspecting = false
 			} else {
 				ui.state = MainUI
 			}
 		}
 		if ui.keyPressedOnce(sdl.SCANCODE_ESCAPE) {
-			input.Input = game.Quit
+			if ui.state != MainUI {
+				ui.state = MainUI
+			} else {
+				input.Input = game.Quit
+			}
 		}
-		// if (ui.keyboardState[sdl.SCANCODE_Q] == 1 && ui.prevKeyboardState[sdl.SCANCODE_Q] == 0) {
-		// 	input.Input = game.Search
-		// }
 
 		for i := range ui.keyboardState {
 			ui.prevKeyboardState[i] = ui.keyboardState[i]
 		}
 
 		if input.Input != game.None {
+			input.Player = level.Player.ID
 			ui.inputChannel <- &input
 		}
 	}
-}
\ No newline at end of file
+}
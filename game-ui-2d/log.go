@@ -0,0 +1,143 @@
+package ui2d
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/ahmadfarhanstwn/rpg/game-logic"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+type logLine struct {
+	text  string
+	color sdl.Color
+}
+
+// wrappedLog caches the wrapped lines of the message log for one panel
+// width, so only new entries need to be measured each frame.
+type wrappedLog struct {
+	width   int
+	entries int
+	lines   []logLine
+}
+
+func eventColor(kind game.GameEvent) sdl.Color {
+	switch kind {
+	case game.Attacking, game.Fired, game.Webbed:
+		return sdl.Color{255,140,0,255}
+	case game.MonsterDeath:
+		return sdl.Color{255,0,0,255}
+	case game.PickUpItems, game.DropItems, game.EquipItems, game.CollectCoin:
+		return sdl.Color{255,215,0,255}
+	case game.DrinkPotion, game.LevelUp, game.Rested, game.AbilityLearned:
+		return sdl.Color{0,200,0,255}
+	case game.TrapTriggered, game.TrapFound, game.AlarmRaised:
+		return sdl.Color{200,0,200,255}
+	case game.AbilityCast, game.Frightened:
+		return sdl.Color{100,150,255,255}
+	case game.Portal:
+		return sdl.Color{0,200,255,255}
+	}
+	return sdl.Color{200,200,200,255}
+}
+
+func wrapText(font *ttf.Font, text string, width int) []string {
+	lines := make([]string, 0, 1)
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		w, _, err := font.SizeUTF8(candidate)
+		if err != nil {
+			panic(err)
+		}
+		if w > width && line != "" {
+			lines = append(lines, line)
+			line = word
+		} else {
+			line = candidate
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (ui *ui) updateWrappedLog(wrapped *wrappedLog, log *game.MessageLog) []logLine {
+	if wrapped.entries > len(log.Entries) {
+		wrapped.entries = 0
+		wrapped.lines = nil
+	}
+	for _, e := range log.Entries[wrapped.entries:] {
+		color := eventColor(e.Kind)
+		for _, text := range wrapText(ui.mediumFont, "["+strconv.Itoa(e.Turn)+"] "+e.String(), wrapped.width) {
+			wrapped.lines = append(wrapped.lines, logLine{text, color})
+		}
+	}
+	wrapped.entries = len(log.Entries)
+	return wrapped.lines
+}
+
+// drawLogLines draws lines bottom-up inside rect, skipping the newest
+// scroll lines, and returns how many lines fit in the rect.
+func (ui *ui) drawLogLines(lines []logLine, rect *sdl.Rect, scroll int) int {
+	lineHeight := int32(ui.mediumFont.Height())
+	visible := int(rect.H / lineHeight)
+	end := len(lines) - scroll
+	y := rect.Y + rect.H
+	for i := end - 1; i >= 0 && i >= end-visible; i-- {
+		tex := ui.stringToFont(lines[i].text, mediumSize, lines[i].color)
+		_,_,w,h,err := tex.Query()
+		if err != nil {
+			panic(err)
+		}
+		y -= lineHeight
+		ui.renderer.Copy(tex, nil, &sdl.Rect{rect.X, y, w, h})
+	}
+	return visible
+}
+
+func (ui *ui) getEventBoxRect() *sdl.Rect {
+	startEventH := int32(float64(ui.winHeight)*0.75)
+	backgroundWidth := int32(float64(ui.winWidth)*0.30)
+	return &sdl.Rect{0, startEventH, backgroundWidth, int32(ui.winHeight)-startEventH}
+}
+
+func (ui *ui) drawEventBox(level *game.Level) {
+	rect := ui.getEventBoxRect()
+	ui.renderer.Copy(ui.eventBackground, nil, rect)
+	ui.eventLog.width = int(rect.W) - 8
+	lines := ui.updateWrappedLog(&ui.eventLog, level.Log)
+	ui.drawLogLines(lines, &sdl.Rect{rect.X+4, rect.Y, rect.W-8, rect.H}, 0)
+}
+
+func (ui *ui) getLogRect() *sdl.Rect {
+	logWidth := int32(float32(ui.winWidth)*.80)
+	logHeight := int32(float32(ui.winHeight)*.80)
+	return &sdl.Rect{(int32(ui.winWidth)-logWidth)/2, (int32(ui.winHeight)-logHeight)/2, logWidth, logHeight}
+}
+
+func (ui *ui) scrollLog(lines int) {
+	ui.logScroll += lines
+	maxScroll := len(ui.fullLog.lines) - ui.logVisible
+	if ui.logScroll > maxScroll {
+		ui.logScroll = maxScroll
+	}
+	if ui.logScroll < 0 {
+		ui.logScroll = 0
+	}
+}
+
+func (ui *ui) DrawLog(level *game.Level) {
+	rect := ui.getLogRect()
+	ui.renderer.Copy(ui.inventoryBorder, nil, &sdl.Rect{rect.X-3, rect.Y-3, rect.W+6, rect.H+6})
+	ui.renderer.Copy(ui.inventoryBackground, nil, rect)
+
+	ui.fullLog.width = int(rect.W) - 20
+	lines := ui.updateWrappedLog(&ui.fullLog, level.Log)
+	ui.logVisible = ui.drawLogLines(lines, &sdl.Rect{rect.X+10, rect.Y+10, rect.W-20, rect.H-20}, ui.logScroll)
+}
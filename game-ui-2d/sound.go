@@ -4,6 +4,7 @@ import (
 	"math/rand"
 	"strconv"
 
+	"github.com/ahmadfarhanstwn/rpg/game-logic"
 	"github.com/veandco/go-sdl2/mix"
 )
 
@@ -15,6 +16,7 @@ type sounds struct {
 	enteringPortals []*mix.Chunk
 	deathSound []*mix.Chunk
 	burpSound []*mix.Chunk
+	tileSounds map[string][]*mix.Chunk
 }
 
 func (ui *ui) initSound() {
@@ -44,6 +46,8 @@ func (ui *ui) initSound() {
 	}
 	ui.sounds.openDoor = append(ui.sounds.openDoor, openDoorSound)
 
+	ui.sounds.tileSounds = map[string][]*mix.Chunk{"dirt": ui.sounds.footStep, "door": ui.sounds.openDoor}
+
 	stepBase = "game-ui-2d/assets/Mudchute_pig_"
 	for i := 1; i <= 3; i++ {
 		soundFile := stepBase + strconv.Itoa(i) + ".ogg"
@@ -87,4 +91,44 @@ func playRandomSounds(chunks []*mix.Chunk, volume int) {
 	r := rand.Intn(len(chunks))
 	chunks[r].Volume(volume)
 	chunks[r].Play(-1,0)
-}
\ No newline at end of file
+}
+
+func (ui *ui) playEventSound(level *game.Level, e game.Event) {
+	switch e.Kind {
+	case game.Move:
+		ui.playTileSound(game.TileDefinition(level.Map[e.Pos.Y][e.Pos.X].Rune), 20)
+	case game.OpenDoor, game.UnlockDoor:
+		ui.playTileSound(game.TileDefinition(level.Map[e.Pos.Y][e.Pos.X].OverlayRune), 75)
+	case game.TrapTriggered:
+		playRandomSounds(ui.attackingSound, 75)
+	case game.DoorLocked:
+		playRandomSounds(ui.pickUpItems, 30)
+	case game.Attacking:
+		playRandomSounds(ui.attackingSound, 75)
+	case game.Fired:
+		playRandomSounds(ui.attackingSound, 40)
+	case game.PickUpItems, game.DropItems, game.EquipItems, game.CollectCoin:
+		playRandomSounds(ui.pickUpItems, 75)
+	case game.InventoryFull:
+		playRandomSounds(ui.openDoor, 50)
+	case game.Portal:
+		playRandomSounds(ui.enteringPortals, 100)
+	case game.MonsterDeath:
+		playRandomSounds(ui.deathSound, 100)
+	case game.DrinkPotion:
+		playRandomSounds(ui.burpSound, 100)
+	case game.LevelUp, game.AbilityLearned:
+		playRandomSounds(ui.enteringPortals, 100)
+	case game.AbilityCast:
+		playRandomSounds(ui.enteringPortals, 50)
+	}
+}
+
+// playTileSound plays the sound the registry names for def, if there are
+// assets for it.
+func (ui *ui) playTileSound(def *game.TileDef, volume int) {
+	chunks := ui.tileSounds[def.Sound]
+	if len(chunks) > 0 {
+		playRandomSounds(chunks, volume)
+	}
+}
@@ -0,0 +1,171 @@
+package ui2d
+
+import (
+	"sort"
+
+	"github.com/ahmadfarhanstwn/rpg/game-logic"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// cameraTarget is who a spectator follows: a player by id or, since
+// monsters have no id, the monster of that name nearest to where it was
+// last seen.
+type cameraTarget struct {
+	player  int
+	monster string
+	pos     game.Pos
+}
+
+func (ui *ui) cameraFocus(level *game.Level) game.Pos {
+	if !level.Spectator {
+		return level.Player.Pos
+	}
+	if ui.freeCamera {
+		return ui.cameraPos
+	}
+	if ui.target.player >= 0 {
+		for _, player := range level.Players {
+			if player.ID == ui.target.player {
+				ui.target.pos = player.Pos
+			}
+		}
+		return ui.target.pos
+	}
+
+	best := -1
+	for pos, monster := range level.Monsters {
+		d := abs(pos.X-ui.target.pos.X) + abs(pos.Y-ui.target.pos.Y)
+		if monster.Name == ui.target.monster && (best == -1 || d < best) {
+			best = d
+			ui.target.pos = pos
+		}
+	}
+	if best == -1 {
+		ui.freeCamera = true
+		ui.cameraPos = ui.target.pos
+	}
+	return ui.target.pos
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// cameraTargets lists the living players by id, then the monsters from
+// top to bottom.
+func cameraTargets(level *game.Level) []cameraTarget {
+	targets := make([]cameraTarget, 0, len(level.Players)+len(level.Monsters))
+	for _, player := range level.Players {
+		if !player.Dead {
+			targets = append(targets, cameraTarget{player.ID, "", player.Pos})
+		}
+	}
+	monsters := make([]cameraTarget, 0, len(level.Monsters))
+	for pos, monster := range level.Monsters {
+		monsters = append(monsters, cameraTarget{-1, monster.Name, pos})
+	}
+	sort.Slice(monsters, func(i, j int) bool {
+		if monsters[i].pos.Y != monsters[j].pos.Y {
+			return monsters[i].pos.Y < monsters[j].pos.Y
+		}
+		return monsters[i].pos.X < monsters[j].pos.X
+	})
+	return append(targets, monsters...)
+}
+
+func (ui *ui) followNext(level *game.Level) {
+	targets := cameraTargets(level)
+	if len(targets) == 0 {
+		return
+	}
+	next := 0
+	if !ui.freeCamera {
+		for i, target := range targets {
+			if target.player == ui.target.player && (target.player >= 0 || target.pos == ui.target.pos) {
+				next = (i + 1) % len(targets)
+				break
+			}
+		}
+	}
+	ui.target = targets[next]
+	ui.freeCamera = false
+}
+
+func (ui *ui) targetName(level *game.Level) string {
+	if ui.freeCamera {
+		return "free camera"
+	}
+	if ui.target.player >= 0 {
+		for _, player := range level.Players {
+			if player.ID == ui.target.player {
+				return player.Name
+			}
+		}
+	}
+	return ui.target.monster
+}
+
+func (ui *ui) drawSpectatorHud(level *game.Level) {
+	lines := []string{"Spectating : " + ui.targetName(level), "Tab : follow next   Arrows : pan"}
+	y := int32(0)
+	for _, line := range lines {
+		tex := ui.stringToFont(line, mediumSize, sdl.Color{255,255,255,0})
+		_,_,w,h,err := tex.Query()
+		if err != nil {
+			panic(err)
+		}
+		ui.renderer.Copy(tex, nil, &sdl.Rect{0,y,w,h})
+		y += h
+	}
+}
+
+func (ui *ui) checkSpectatorInput(level *game.Level) {
+	if sdl.GetKeyboardFocus() == ui.window || sdl.GetMouseFocus() == ui.window {
+		pan := game.Pos{}
+		if ui.keyPressedOnce(sdl.SCANCODE_UP) || ui.keyPressedOnce(sdl.SCANCODE_W) {
+			pan.Y--
+		}
+		if ui.keyPressedOnce(sdl.SCANCODE_DOWN) || ui.keyPressedOnce(sdl.SCANCODE_S) {
+			pan.Y++
+		}
+		if ui.keyPressedOnce(sdl.SCANCODE_LEFT) || ui.keyPressedOnce(sdl.SCANCODE_A) {
+			pan.X--
+		}
+		if ui.keyPressedOnce(sdl.SCANCODE_RIGHT) || ui.keyPressedOnce(sdl.SCANCODE_D) {
+			pan.X++
+		}
+		if pan != (game.Pos{}) {
+			if !ui.freeCamera {
+				ui.cameraPos = ui.cameraFocus(level)
+				ui.freeCamera = true
+			}
+			ui.cameraPos.X += pan.X
+			ui.cameraPos.Y += pan.Y
+		}
+		if ui.keyPressedOnce(sdl.SCANCODE_TAB) {
+			ui.followNext(level)
+		}
+		if ui.keyPressedOnce(sdl.SCANCODE_L) {
+			if ui.state == LogUI {
+				ui.state = MainUI
+			} else {
+				ui.state = LogUI
+				ui.logScroll = 0
+			}
+		}
+		if ui.keyPressedOnce(sdl.SCANCODE_ESCAPE) {
+			if ui.state != MainUI {
+				ui.state = MainUI
+			} else {
+				ui.inputChannel <- &game.Input{Input: game.CloseWindow, LevelChannel: ui.levelChannel}
+			}
+		}
+	}
+
+	for i := range ui.keyboardState {
+		ui.prevKeyboardState[i] = ui.keyboardState[i]
+	}
+}
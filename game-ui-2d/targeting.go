@@ -0,0 +1,153 @@
+package ui2d
+
+import (
+	"sort"
+
+	"github.com/ahmadfarhanstwn/rpg/game-logic"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func (ui *ui) mapOffset() (int, int) {
+	return (ui.winWidth/2) - ui.centerX*32, (ui.winHeight/2) - ui.centerY*32
+}
+
+// visibleMonsters lists where the monsters the player can see are, nearest
+// first.
+func visibleMonsters(level *game.Level) []game.Pos {
+	targets := make([]game.Pos, 0)
+	for pos := range level.Monsters {
+		if level.Map[pos.Y][pos.X].Visible {
+			targets = append(targets, pos)
+		}
+	}
+	from := level.Player.Pos
+	distance := func(pos game.Pos) int {
+		return (pos.X-from.X)*(pos.X-from.X) + (pos.Y-from.Y)*(pos.Y-from.Y)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		di, dj := distance(targets[i]), distance(targets[j])
+		if di != dj {
+			return di < dj
+		}
+		if targets[i].Y != targets[j].Y {
+			return targets[i].Y < targets[j].Y
+		}
+		return targets[i].X < targets[j].X
+	})
+	return targets
+}
+
+// startTargeting lets the player pick a monster to fire at, or to throw
+// item at when it is not nil.
+func (ui *ui) startTargeting(level *game.Level, item *game.Items) {
+	if len(visibleMonsters(level)) == 0 {
+		return
+	}
+	ui.state = TargetUI
+	ui.targetIndex = 0
+	ui.throwItem = item
+	ui.castSlot = -1
+	ui.cursorMode = false
+}
+
+// startCasting casts the player's ability in slot right away if it needs
+// no target, or lets the player pick a monster or, for abilities aimed at
+// a tile, move a cursor.
+func (ui *ui) startCasting(input *game.Input, level *game.Level, slot int) {
+	def := game.AbilityDefinition(level.Player.Abilities[slot].Name)
+	switch def.Target {
+	case game.TargetSelf:
+		input.Input = game.Cast
+		input.Slot = slot
+		return
+	case game.TargetCreature:
+		ui.startTargeting(level, nil)
+	case game.TargetTile:
+		ui.state = TargetUI
+		ui.throwItem = nil
+		ui.cursorMode = true
+		ui.cursor = level.Player.Pos
+	}
+	ui.castSlot = slot
+}
+
+func (ui *ui) currentTarget(level *game.Level) (game.Pos, bool) {
+	if ui.cursorMode {
+		return ui.cursor, true
+	}
+	targets := visibleMonsters(level)
+	if len(targets) == 0 {
+		return game.Pos{}, false
+	}
+	ui.targetIndex = (ui.targetIndex%len(targets) + len(targets)) % len(targets)
+	return targets[ui.targetIndex], true
+}
+
+func (ui *ui) checkTargetKeys(input *game.Input, level *game.Level) {
+	if ui.cursorMode {
+		if ui.keyPressedOnce(sdl.SCANCODE_UP) || ui.keyPressedOnce(sdl.SCANCODE_W) {
+			ui.cursor.Y--
+		}
+		if ui.keyPressedOnce(sdl.SCANCODE_DOWN) || ui.keyPressedOnce(sdl.SCANCODE_S) {
+			ui.cursor.Y++
+		}
+		if ui.keyPressedOnce(sdl.SCANCODE_LEFT) || ui.keyPressedOnce(sdl.SCANCODE_A) {
+			ui.cursor.X--
+		}
+		if ui.keyPressedOnce(sdl.SCANCODE_RIGHT) || ui.keyPressedOnce(sdl.SCANCODE_D) {
+			ui.cursor.X++
+		}
+	} else {
+		if ui.keyPressedOnce(sdl.SCANCODE_TAB) || ui.keyPressedOnce(sdl.SCANCODE_RIGHT) || ui.keyPressedOnce(sdl.SCANCODE_DOWN) {
+			ui.targetIndex++
+		}
+		if ui.keyPressedOnce(sdl.SCANCODE_LEFT) || ui.keyPressedOnce(sdl.SCANCODE_UP) {
+			ui.targetIndex--
+		}
+	}
+	target, ok := ui.currentTarget(level)
+	if !ok {
+		ui.state = MainUI
+		return
+	}
+	if ui.keyPressedOnce(sdl.SCANCODE_F) || ui.keyPressedOnce(sdl.SCANCODE_RETURN) {
+		if ui.castSlot >= 0 {
+			input.Input = game.Cast
+			input.Slot = ui.castSlot
+		} else {
+			input.Input = game.Fire
+			input.Item = ui.throwItem
+		}
+		input.Target = target
+		ui.state = MainUI
+	}
+}
+
+func (ui *ui) drawTargeting(level *game.Level) {
+	target, ok := ui.currentTarget(level)
+	if !ok {
+		return
+	}
+	offsetX, offsetY := ui.mapOffset()
+	rect := sdl.Rect{int32(target.X*32+offsetX), int32(target.Y*32+offsetY), 32, 32}
+	ui.renderer.SetDrawColor(255, 0, 0, 255)
+	ui.renderer.DrawRect(&rect)
+	ui.renderer.SetDrawColor(0, 0, 0, 255)
+
+	action := "F : fire"
+	if ui.castSlot >= 0 {
+		action = "F : cast " + level.Player.Abilities[ui.castSlot].Name
+	} else if ui.throwItem != nil {
+		action = "F : throw " + ui.throwItem.Name
+	}
+	text := "Arrows : move   " + action + "   Esc : cancel"
+	if !ui.cursorMode {
+		text = level.Monsters[target].Name + "   Tab : next   " + action + "   Esc : cancel"
+	}
+	tex := ui.stringToFont(text, mediumSize, sdl.Color{255,255,255,0})
+	_,_,w,h,err := tex.Query()
+	if err != nil {
+		panic(err)
+	}
+	ui.renderer.Copy(tex, nil, &sdl.Rect{rect.X+16-w/2, rect.Y-h, w, h})
+}
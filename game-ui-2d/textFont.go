@@ -1,12 +1,15 @@
 package ui2d
 
 import (
+	"fmt"
+
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/ttf"
 )
 
-func (ui *ui) stringToFont(s string, size fontSize, color sdl.Color) *sdl.Texture {
+func (ui *ui) stringToFont(text string, size fontSize, color sdl.Color) *sdl.Texture {
 	var font *ttf.Font
+	s := text + "|" + fmt.Sprint(color)
 
 	switch size {
 	case smallSize:
@@ -29,7 +32,7 @@ func (ui *ui) stringToFont(s string, size fontSize, color sdl.Color) *sdl.Textur
 		font = ui.largeFont
 	}
 
-	fontSurface, err := font.RenderUTF8Blended(s, color)
+	fontSurface, err := font.RenderUTF8Blended(text, color)
 	if err != nil {
 		panic(err)
 	}
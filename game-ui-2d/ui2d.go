@@ -17,6 +17,8 @@ type UiState int
 const (
 	MainUI UiState = iota
 	InventoryUI
+	LogUI
+	TargetUI
 )
 
 const (
@@ -73,14 +75,31 @@ type ui struct {
 	helmetSlotBackground *sdl.Texture
 	swordSlotBackground *sdl.Texture
 	armourSlotBackground *sdl.Texture
+	xpBarBackground *sdl.Texture
+	xpBarFill *sdl.Texture
 	draggedItem *game.Items
+	selectedItem int
+	inspecting bool
+	eventLog wrappedLog
+	fullLog wrappedLog
+	logScroll int
+	logVisible int
 	currMouseState *mouseState
 	prevMouseState *mouseState
+	target cameraTarget
+	freeCamera bool
+	cameraPos game.Pos
+	targetIndex int
+	throwItem *game.Items
+	castSlot int
+	cursorMode bool
+	cursor game.Pos
 }
 
 func NewUi(levelChannel chan *game.Level, inputChannel chan *game.Input) *ui {
 	ui := &ui{}
 	ui.state = MainUI
+	ui.castSlot = -1
 	ui.r = rand.New(rand.NewSource(1))
 	ui.levelChannel = levelChannel
 	ui.inputChannel = inputChannel
@@ -156,29 +175,36 @@ func NewUi(levelChannel chan *game.Level, inputChannel chan *game.Input) *ui {
 	ui.armourSlotBackground = ui.GetSinglePixelTex(sdl.Color{255,0,0,128})
 	ui.armourSlotBackground.SetBlendMode(sdl.BLENDMODE_BLEND)
 
+	ui.xpBarBackground = ui.GetSinglePixelTex(sdl.Color{43,43,43,255})
+	ui.xpBarBackground.SetBlendMode(sdl.BLENDMODE_BLEND)
+
+	ui.xpBarFill = ui.GetSinglePixelTex(sdl.Color{212,175,55,255})
+	ui.xpBarFill.SetBlendMode(sdl.BLENDMODE_BLEND)
+
 	ui.initSound()
 
 	return ui
 }
 
 func (ui *ui) Draw(level *game.Level) {
+	focus := ui.cameraFocus(level)
 
-	if ui.centerX == -1 && ui.centerY == -1 {
-		ui.centerX = level.Player.X
-		ui.centerY = level.Player.Y
+	if (ui.centerX == -1 && ui.centerY == -1) || ui.freeCamera {
+		ui.centerX = focus.X
+		ui.centerY = focus.Y
 	}
 
-	if level.Player.X > ui.centerX+ui.cameraLimit {
-		diff := level.Player.X - (ui.centerX+ui.cameraLimit)
+	if focus.X > ui.centerX+ui.cameraLimit {
+		diff := focus.X - (ui.centerX+ui.cameraLimit)
 		ui.centerX += diff
-	} else if level.Player.X < ui.centerX-ui.cameraLimit {
-		diff := (ui.centerX-ui.cameraLimit) - level.Player.X
+	} else if focus.X < ui.centerX-ui.cameraLimit {
+		diff := (ui.centerX-ui.cameraLimit) - focus.X
 		ui.centerX -= diff
-	} else if level.Player.Y > ui.centerY+ui.cameraLimit {
-		diff := level.Player.Y - (ui.centerY+ui.cameraLimit)
+	} else if focus.Y > ui.centerY+ui.cameraLimit {
+		diff := focus.Y - (ui.centerY+ui.cameraLimit)
 		ui.centerY += diff
-	} else if level.Player.Y < ui.centerY-ui.cameraLimit {
-		diff := (ui.centerY-ui.cameraLimit) - level.Player.Y
+	} else if focus.Y < ui.centerY-ui.cameraLimit {
+		diff := (ui.centerY-ui.cameraLimit) - focus.Y
 		ui.centerY -= diff
 	}
 
@@ -190,8 +216,9 @@ func (ui *ui) Draw(level *game.Level) {
 	for y, rows := range level.Map {
 		for x, cols := range rows {
 			if cols.Rune != game.Blank {
-				r := ui.r.Intn(len(ui.textureIndex[cols.Rune]))
-				srcRect := ui.textureIndex[cols.Rune][r]
+				sprite := game.TileDefinition(cols.Rune).Sprite
+				r := ui.r.Intn(len(ui.textureIndex[sprite]))
+				srcRect := ui.textureIndex[sprite][r]
 				if level.Map[y][x].Visible || level.Map[y][x].Seen {
 					destRect := sdl.Rect{int32(x*32)+int32(offsetX),int32(y*32)+int32(offsetY),32,32}
 
@@ -206,7 +233,7 @@ func (ui *ui) Draw(level *game.Level) {
 					ui.renderer.Copy(ui.imageAtlas, &srcRect, &destRect)
 
 					if level.Map[y][x].OverlayRune != game.Blank {
-						srcRect = ui.textureIndex[cols.OverlayRune][0]
+						srcRect = ui.textureIndex[game.TileDefinition(cols.OverlayRune).Sprite][0]
 						ui.renderer.Copy(ui.imageAtlas, &srcRect, &destRect)
 					}
 				}
@@ -216,6 +243,13 @@ func (ui *ui) Draw(level *game.Level) {
 
 	ui.imageAtlas.SetColorMod(255,255,255)
 
+	for pos, trap := range level.Traps {
+		if !trap.Hidden && level.Map[pos.Y][pos.X].Seen {
+			trapRect := ui.textureIndex[trap.Rune][0]
+			ui.renderer.Copy(ui.imageAtlas, &trapRect, &sdl.Rect{int32(pos.X*32)+int32(offsetX),int32(pos.Y*32)+int32(offsetY),32,32})
+		}
+	}
+
 	for pos, monster := range level.Monsters {
 		if level.Map[pos.Y][pos.X].Visible {
 			monsterRect := ui.textureIndex[monster.Rune][0]
@@ -235,39 +269,38 @@ func (ui *ui) Draw(level *game.Level) {
 	}
 
 	playerSrc := ui.textureIndex['@'][0]
+	for _, player := range level.Players {
+		if player != level.Player && !player.Dead && level.Map[player.Y][player.X].Visible {
+			ui.renderer.Copy(ui.imageAtlas, &playerSrc, &sdl.Rect{int32(player.X*32)+int32(offsetX),int32(player.Y*32)+int32(offsetY),32,32})
+		}
+	}
 	ui.renderer.Copy(ui.imageAtlas, &playerSrc, &sdl.Rect{int32(level.Player.X*32)+int32(offsetX),int32(level.Player.Y*32)+int32(offsetY),32,32})
 	
-	startEventH := int32(float64(ui.winHeight)*0.75)
-	backgroundWidth := int32(float64(ui.winWidth)*0.30)
-	
-	ui.renderer.Copy(ui.eventBackground, nil, &sdl.Rect{0, startEventH, backgroundWidth, int32(ui.winHeight)-startEventH})
-
-	for i, event := range level.Events {
-		if event != "" {
-			tex := ui.stringToFont(event, mediumSize, sdl.Color{255,0,0,0})
-			_,_,w,h,err := tex.Query()
-			if err != nil {
-				panic(err)
-			}
-			ui.renderer.Copy(tex, nil, &sdl.Rect{0,int32(i*12)+startEventH,w,h})
-		}
+	ui.drawEventBox(level)
+
+	if level.Spectator {
+		ui.drawSpectatorHud(level)
+		return
 	}
 
-	hp := ui.stringToFont("Player HP : "+strconv.FormatInt(int64(level.Player.Hp),10),mediumSize, sdl.Color{255,255,255,0})
+	hp := ui.stringToFont("Player HP : "+strconv.FormatInt(int64(level.Player.Hp),10)+"/"+strconv.FormatInt(int64(level.Player.MaxHp),10),mediumSize, sdl.Color{255,255,255,0})
 	_,_,w,h,err := hp.Query()
 	if err != nil {
 		panic(err)
 	}
 	ui.renderer.Copy(hp, nil, &sdl.Rect{0,0,w,h})
 
-	coins := ui.stringToFont("Coins : "+strconv.FormatInt(int64(level.Coins),10) + " /5",mediumSize, sdl.Color{255,255,255,0})
+	ui.drawExperienceBar(level, h)
+
+	coins := ui.stringToFont("Coins : "+strconv.FormatInt(int64(level.Player.Coins),10) + " /5",mediumSize, sdl.Color{255,255,255,0})
 	_,_,w,h,err = hp.Query()
 	if err != nil {
 		panic(err)
 	}
 	ui.renderer.Copy(coins, nil, &sdl.Rect{int32(float32(ui.winWidth)/1.5),0,w,h})
+	ui.drawAbilities(level, int32(float32(ui.winWidth)/1.5), h)
 
-	if level.LastEvent == game.FailedPortal {
+	if level.HasEvent(game.FailedPortal) {
 		reminder := ui.stringToFont("You haven't collected 5 coins. Find more coins to proceed to the next level",mediumSize, sdl.Color{255,255,255,0})
 		_,_,w,h,err = reminder.Query()
 		if err != nil {
@@ -286,9 +319,56 @@ func (ui *ui) Draw(level *game.Level) {
 		itemRect := ui.textureIndex[item.Rune][0]
 		ui.renderer.Copy(ui.imageAtlas, &itemRect, ui.getBackgroundRect(i))
 	}
+
+	ui.drawQuickSlots(level)
 	// sdl.Delay(16)
 }
 
+func (ui *ui) drawExperienceBar(level *game.Level, startY int32) {
+	lvl := ui.stringToFont("Level : "+strconv.FormatInt(int64(level.Player.Level),10),mediumSize, sdl.Color{255,255,255,0})
+	_,_,w,h,err := lvl.Query()
+	if err != nil {
+		panic(err)
+	}
+	ui.renderer.Copy(lvl, nil, &sdl.Rect{0,startY,w,h})
+
+	barWidth := int32(float64(ui.winWidth)*0.20)
+	barHeight := h/2
+	barY := startY + h
+	needed := game.ExperienceToLevelUp(level.Player.Level)
+	fillWidth := barWidth*int32(level.Player.Experience)/int32(needed)
+	ui.renderer.Copy(ui.xpBarBackground, nil, &sdl.Rect{0,barY,barWidth,barHeight})
+	ui.renderer.Copy(ui.xpBarFill, nil, &sdl.Rect{0,barY,fillWidth,barHeight})
+}
+
+func (ui *ui) getQuickSlotRect(slot int) *sdl.Rect {
+	slotSize := int32(36)
+	totalWidth := int32(game.QuickSlotCount)*(slotSize+2)
+	startX := (int32(ui.winWidth)-totalWidth)/2
+	itemSize := int32(itemSizeRatio * float32(ui.winWidth))
+	y := int32(ui.winHeight)-itemSize-slotSize-4
+	return &sdl.Rect{startX+int32(slot)*(slotSize+2), y, slotSize, slotSize}
+}
+
+func (ui *ui) drawQuickSlots(level *game.Level) {
+	for i := 0; i < game.QuickSlotCount; i++ {
+		slotRect := ui.getQuickSlotRect(i)
+		ui.renderer.Copy(ui.characterSlotBackground, nil, slotRect)
+		item := level.Player.QuickSlotItem(i)
+		if item != nil {
+			itemRect := ui.textureIndex[item.Rune][0]
+			ui.renderer.Copy(ui.imageAtlas, &itemRect, slotRect)
+			ui.drawStackCount(item, slotRect)
+		}
+		key := ui.stringToFont(strconv.Itoa(i+1), smallSize, sdl.Color{255,255,255,0})
+		_,_,w,h,err := key.Query()
+		if err != nil {
+			panic(err)
+		}
+		ui.renderer.Copy(key, nil, &sdl.Rect{slotRect.X+2, slotRect.Y+1, w, h})
+	}
+}
+
 func (ui *ui) Run() {
 	ui.prevMouseState = getMouseState()
 	var newLevel *game.Level
@@ -296,7 +376,15 @@ func (ui *ui) Run() {
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
 			switch e := event.(type) {
 			case *sdl.QuitEvent:
-				ui.inputChannel <- &game.Input{Input: game.Quit}
+				if newLevel != nil && newLevel.Spectator {
+					ui.inputChannel <- &game.Input{Input: game.CloseWindow,LevelChannel: ui.levelChannel}
+				} else {
+					ui.inputChannel <- &game.Input{Input: game.Quit}
+				}
+			case *sdl.MouseWheelEvent:
+				if ui.state == LogUI {
+					ui.scrollLog(int(e.Y)*3)
+				}
 			case *sdl.WindowEvent:
 				if e.Event == sdl.WINDOWEVENT_CLOSE {
 					ui.inputChannel <- &game.Input{Input: game.CloseWindow,LevelChannel: ui.levelChannel}
@@ -310,28 +398,29 @@ func (ui *ui) Run() {
 
 		select {
 		case newLevel, ok = <-ui.levelChannel:
-			if ok {
-				switch newLevel.LastEvent {
-				case game.Move:
-					playRandomSounds(ui.sounds.footStep,20)
-				case game.OpenDoor:
-					playRandomSounds(ui.openDoor, 75)
-				case game.Attacking:
-					playRandomSounds(ui.attackingSound, 75)
-				case game.PickUpItems, game.DropItems:
-					playRandomSounds(ui.pickUpItems, 75)
-				case game.Portal:
-					playRandomSounds(ui.enteringPortals, 100)
-				case game.MonsterDeath:
-					playRandomSounds(ui.deathSound, 100)
-				// case game.DrinkPotion:
-				// 	playRandomSounds(ui.burpSound, 100)
-				}
+			if !ok {
+				return
+			}
+			for _, e := range newLevel.TurnEvents {
+				ui.playEventSound(newLevel, e)
 			}
 		default:
 		}
+		if newLevel == nil {
+			sdl.Delay(10)
+			continue
+		}
 
 		ui.Draw(newLevel)
+		if newLevel.Spectator {
+			if ui.state == LogUI {
+				ui.DrawLog(newLevel)
+			}
+			ui.renderer.Present()
+			ui.checkSpectatorInput(newLevel)
+			sdl.Delay(10)
+			continue
+		}
 		if ui.state == InventoryUI {
 			if ui.draggedItem != nil && !ui.currMouseState.leftButton && ui.prevMouseState.leftButton {
 				item := ui.CheckDroppedItem()
@@ -352,7 +441,17 @@ func (ui *ui) Run() {
 				ui.draggedItem = ui.CheckInventoryItems(newLevel)	
 			}
 			ui.DrawInventory(newLevel)
-			ui.DrinkPotion(newLevel)
+			item := ui.DrinkPotion(newLevel)
+			if item != nil {
+				input.Input = game.UseItem
+				input.Item = item
+			}
+		}
+		if ui.state == LogUI {
+			ui.DrawLog(newLevel)
+		}
+		if ui.state == TargetUI {
+			ui.drawTargeting(newLevel)
 		}
 		ui.renderer.Present()
 
@@ -360,9 +459,14 @@ func (ui *ui) Run() {
 		if item != nil {
 			input.Input = game.TakeItem
 			input.Item = item
+		} else if ui.state == MainUI {
+			if target, ok := ui.CheckMapClick(newLevel); ok {
+				input.Input = game.Travel
+				input.Target = target
+			}
 		}
 
-		ui.checkInput(input)
+		ui.checkInput(input, newLevel)
 		ui.prevMouseState = ui.currMouseState
 		sdl.Delay(10)
 	}
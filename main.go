@@ -11,20 +11,83 @@
 package main
 
 import (
+	"flag"
+	"net"
+	"net/http"
 	"runtime"
 
 	game "github.com/ahmadfarhanstwn/rpg/game-logic"
+	gamenet "github.com/ahmadfarhanstwn/rpg/game-net"
 	ui2d "github.com/ahmadfarhanstwn/rpg/game-ui-2d"
 )
 
 func main() {
-	game := game.NewGame(1)
-	for i := 0; i < 1; i++ {
+	players := flag.Int("players", 1, "number of local players, each with their own window")
+	serve := flag.String("serve", "", "address to host a game on for remote players instead of opening windows")
+	connect := flag.String("connect", "", "address of a hosted game to join")
+	spectators := flag.Int("spectators", 0, "number of extra windows that only watch the game")
+	spectate := flag.Bool("spectate", false, "join the -connect game as a spectator")
+	fullView := flag.Bool("fullview", false, "let spectators see the whole level instead of what the players see")
+	httpAddr := flag.String("http", "", "address to serve a JSON API on, for an extra player driven by scripts (not with -serve)")
+	flag.Parse()
+
+	if *connect != "" {
+		var client *gamenet.Client
+		var err error
+		if *spectate {
+			client, err = gamenet.DialSpectator(*connect, *fullView)
+		} else {
+			client, err = gamenet.Dial(*connect)
+		}
+		if err != nil {
+			panic(err)
+		}
+		go func() {
+			runtime.LockOSThread()
+			ui := ui2d.NewUi(client.LevelChan, client.InputChan)
+			ui.Run()
+		}()
+		client.Run()
+		return
+	}
+
+	windows := *players
+	if *httpAddr != "" && *serve == "" {
+		windows++
+	}
+	game := game.NewGame(windows)
+	if *serve != "" {
+		listener, err := net.Listen("tcp", *serve)
+		if err != nil {
+			panic(err)
+		}
+		go func() {
+			panic(gamenet.NewServer(game).Serve(listener))
+		}()
+		game.Run()
+		return
+	}
+
+	if *httpAddr != "" {
+		handler := gamenet.NewHTTPHandler(game.LevelChan[windows-1], game.InputChan)
+		go func() {
+			panic(http.ListenAndServe(*httpAddr, handler))
+		}()
+	}
+	for i := 0; i < *players; i++ {
 		go func(i int) {
 			runtime.LockOSThread()
 			ui := ui2d.NewUi(game.LevelChan[i], game.InputChan)
 			ui.Run()
 		}(i)
 	}
+	for i := 0; i < *spectators; i++ {
+		spectator := game.AddSpectator(*fullView)
+		go func() {
+			runtime.LockOSThread()
+			ui := ui2d.NewUi(spectator.LevelChan, game.InputChan)
+			ui.Run()
+		}()
+	}
 	game.Run()
 }
\ No newline at end of file